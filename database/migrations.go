@@ -0,0 +1,145 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+)
+
+// migrations berisi perubahan schema yang dijalankan setiap startup
+// Setiap statement harus idempotent (IF NOT EXISTS) karena dijalankan ulang setiap kali aplikasi start
+var migrations = []string{
+	// min_stock: batas stok minimum per produk, NULL berarti tidak ada batas
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS min_stock INTEGER`,
+	// stock_movements: riwayat perubahan stok beserta alasannya
+	`CREATE TABLE IF NOT EXISTS stock_movements (
+		id SERIAL PRIMARY KEY,
+		product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+		delta INTEGER NOT NULL,
+		reason TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	// expiry_date: tanggal kadaluarsa produk, NULL untuk produk yang tidak kadaluarsa
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS expiry_date DATE`,
+	// is_favorite: produk yang tampil di bar favorit layar POS
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS is_favorite BOOLEAN NOT NULL DEFAULT FALSE`,
+	// customers: pelanggan program loyalty beserta saldo poinnya
+	`CREATE TABLE IF NOT EXISTS customers (
+		id SERIAL PRIMARY KEY,
+		phone TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL DEFAULT '',
+		points INTEGER NOT NULL DEFAULT 0,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS customer_id INTEGER REFERENCES customers(id)`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_earned INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS points_redeemed INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS loyalty_discount INTEGER NOT NULL DEFAULT 0`,
+	// voided_at: waktu transaksi dibatalkan (void), NULL berarti transaksi masih berlaku
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS voided_at TIMESTAMP`,
+	// idempotency_key: key dari client agar checkout yang dikirim ulang tidak tercatat dua kali
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS idempotency_key TEXT`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_idempotency_key ON transactions (idempotency_key) WHERE idempotency_key IS NOT NULL`,
+	// status transaksi: completed, cancelled (dibatalkan per transaksi), voided (void massal)
+	// Transaksi cancelled dan voided sama-sama punya voided_at sehingga tidak dihitung di laporan
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'completed'`,
+	// discount per item transaksi, subtotal yang tersimpan sudah dikurangi diskon ini
+	`ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS discount INTEGER NOT NULL DEFAULT 0`,
+	// subtotal, discount, dan tax transaksi: total_amount = subtotal - discount - loyalty_discount + tax
+	// Transaksi lama tidak punya diskon dan pajak sehingga subtotal-nya diisi dari total_amount + loyalty_discount
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS subtotal INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS discount INTEGER NOT NULL DEFAULT 0`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tax INTEGER NOT NULL DEFAULT 0`,
+	`UPDATE transactions SET subtotal = total_amount + loyalty_discount WHERE subtotal = 0 AND total_amount > 0`,
+	// payment_method dan amount_paid, transaksi lama dianggap dibayar tunai pas
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS payment_method TEXT NOT NULL DEFAULT 'cash'`,
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS amount_paid INTEGER NOT NULL DEFAULT 0`,
+	`UPDATE transactions SET amount_paid = total_amount WHERE amount_paid = 0 AND total_amount > 0`,
+	`UPDATE transactions SET status = 'voided' WHERE voided_at IS NOT NULL AND status = 'completed'`,
+	// min_stock kategori: default batas stok minimum untuk produk yang min_stock-nya NULL
+	`ALTER TABLE categories ADD COLUMN IF NOT EXISTS min_stock INTEGER`,
+	// is_tax_exempt: produk yang tidak dikenai pajak
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS is_tax_exempt BOOLEAN NOT NULL DEFAULT FALSE`,
+	// taxable_subtotal: bagian subtotal transaksi dari produk yang kena pajak, sisanya bebas pajak
+	// Transaksi lama diisi dari status is_tax_exempt produk saat migrasi dijalankan
+	`ALTER TABLE transactions ADD COLUMN IF NOT EXISTS taxable_subtotal INTEGER`,
+	`UPDATE transactions t SET taxable_subtotal = COALESCE((
+		SELECT SUM(td.subtotal)
+		FROM transaction_details td
+		LEFT JOIN products p ON p.id = td.product_id
+		WHERE td.transaction_id = t.id AND NOT COALESCE(p.is_tax_exempt, FALSE)
+	), 0) WHERE taxable_subtotal IS NULL`,
+	// sku: kode barcode produk, unik tetapi boleh kosong (NULL) untuk produk tanpa barcode
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS sku TEXT`,
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku) WHERE sku IS NOT NULL`,
+	// created_at dan updated_at produk, produk lama mendapat waktu saat migrasi dijalankan
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW()`,
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW()`,
+	// cost_price: harga modal produk untuk laporan laba, produk lama dianggap 0 sampai diisi
+	`ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price INTEGER NOT NULL DEFAULT 0`,
+	// Nama produk unik tanpa membedakan huruf besar/kecil
+	// Index hanya dibuat jika belum ada nama ganda agar startup tidak gagal pada database lama; duplikat lama harus dirapikan manual
+	`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM products GROUP BY LOWER(name) HAVING COUNT(*) > 1) THEN
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_products_name_lower ON products (LOWER(name));
+		END IF;
+	END $$`,
+	// product_audit_logs: riwayat semua perubahan produk
+	// Sengaja tanpa foreign key agar riwayat produk yang sudah dihapus tetap ada
+	`CREATE TABLE IF NOT EXISTS product_audit_logs (
+		id SERIAL PRIMARY KEY,
+		product_id INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		payload JSONB NOT NULL DEFAULT '{}',
+		created_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_product_audit_logs_product_id ON product_audit_logs (product_id, created_at)`,
+	// price_snapshots: promo set berisi harga produk yang disimpan dengan nama tertentu
+	// Tanpa foreign key agar produk yang sudah dihapus bisa dilaporkan sebagai skipped saat apply
+	`CREATE TABLE IF NOT EXISTS price_snapshots (
+		id SERIAL PRIMARY KEY,
+		name TEXT NOT NULL,
+		product_id INTEGER NOT NULL,
+		price INTEGER NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+		UNIQUE (name, product_id)
+	)`,
+	// daily_snapshots: ringkasan tutup kasir per tanggal yang disimpan permanen
+	`CREATE TABLE IF NOT EXISTS daily_snapshots (
+		tanggal DATE PRIMARY KEY,
+		total_revenue INTEGER NOT NULL,
+		total_transaksi INTEGER NOT NULL,
+		item_terjual INTEGER NOT NULL,
+		top_products JSONB NOT NULL DEFAULT '[]',
+		snapshot_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`,
+	// payment_methods: rincian revenue per metode pembayaran di snapshot, snapshot lama berisi array kosong
+	`ALTER TABLE daily_snapshots ADD COLUMN IF NOT EXISTS payment_methods JSONB NOT NULL DEFAULT '[]'`,
+}
+
+// Migrate menjalankan semua migrations secara berurutan
+func Migrate(db *sql.DB) error {
+	for _, statement := range migrations {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	log.Print("Database migrations applied")
+	warnMissingProductNameIndex(db)
+	return nil
+}
+
+// warnMissingProductNameIndex mencatat warning jika idx_products_name_lower tidak dibuat oleh migration
+// karena masih ada nama produk ganda, agar operator tahu nama unik belum dijaga oleh database
+func warnMissingProductNameIndex(db *sql.DB) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM pg_indexes WHERE schemaname = current_schema() AND indexname = 'idx_products_name_lower')").Scan(&exists)
+	if err != nil {
+		log.Printf("WARNING: failed to check idx_products_name_lower: %v", err)
+		return
+	}
+	if !exists {
+		log.Print("WARNING: idx_products_name_lower was not created because duplicate product names exist (case-insensitive); rename the duplicates and restart to enforce unique names")
+	}
+}
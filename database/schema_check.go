@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"sort"
+
+	"github.com/lib/pq"
+)
+
+// expectedColumns adalah kolom yang dipakai oleh query di repository, per tabel
+// Harus ikut diperbarui setiap kali ada kolom baru di migrations
+var expectedColumns = map[string][]string{
+	"categories":          {"id", "name", "description", "min_stock"},
+	"products":            {"id", "name", "price", "stock", "category_id", "min_stock", "expiry_date", "is_favorite", "is_tax_exempt", "sku", "created_at", "updated_at", "cost_price"},
+	"transactions":        {"id", "total_amount", "created_at", "customer_id", "points_earned", "points_redeemed", "loyalty_discount", "voided_at", "idempotency_key", "status", "subtotal", "discount", "tax", "payment_method", "amount_paid", "taxable_subtotal"},
+	"transaction_details": {"id", "transaction_id", "product_id", "quantity", "subtotal", "discount"},
+	"stock_movements":     {"id", "product_id", "delta", "reason", "created_at"},
+	"customers":           {"id", "phone", "name", "points", "created_at"},
+	"product_audit_logs":  {"id", "product_id", "action", "payload", "created_at"},
+	"price_snapshots":     {"id", "name", "product_id", "price", "created_at"},
+	"daily_snapshots":     {"tanggal", "total_revenue", "total_transaksi", "item_terjual", "top_products", "snapshot_at", "payment_methods"},
+}
+
+// SchemaMismatch adalah kolom yang diharapkan aplikasi tetapi tidak ada di database
+type SchemaMismatch struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+// CheckSchema membandingkan expectedColumns dengan information_schema.columns
+// Mengembalikan daftar kolom yang hilang (kosong berarti schema sesuai)
+func CheckSchema(db *sql.DB) ([]SchemaMismatch, error) {
+	tables := make([]string, 0, len(expectedColumns))
+	for table := range expectedColumns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	rows, err := db.Query(`
+		SELECT table_name, column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ANY($1)
+	`, pq.Array(tables))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actual := make(map[string]map[string]bool, len(tables))
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		if actual[table] == nil {
+			actual[table] = make(map[string]bool)
+		}
+		actual[table][column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	mismatches := make([]SchemaMismatch, 0)
+	for _, table := range tables {
+		for _, column := range expectedColumns[table] {
+			if !actual[table][column] {
+				mismatches = append(mismatches, SchemaMismatch{Table: table, Column: column})
+			}
+		}
+	}
+	return mismatches, nil
+}
+
+// LogSchemaCheck menjalankan CheckSchema saat startup dan mencatat setiap kolom yang hilang
+// Aplikasi tetap jalan agar operator bisa melihat detailnya lewat /api/admin/schema-check
+func LogSchemaCheck(db *sql.DB) {
+	mismatches, err := CheckSchema(db)
+	if err != nil {
+		log.Printf("WARNING: schema check failed: %v", err)
+		return
+	}
+	for _, m := range mismatches {
+		log.Printf("WARNING: schema mismatch, column %s.%s does not exist", m.Table, m.Column)
+	}
+	if len(mismatches) == 0 {
+		log.Print("Database schema check passed")
+	}
+}
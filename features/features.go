@@ -0,0 +1,67 @@
+package features
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Nama feature flag yang dikenal aplikasi
+// Nilainya dibaca dari env FEATURE_<NAMA>, contoh FEATURE_LOYALTY=false
+const (
+	Tax      = "tax"
+	Discount = "discount"
+	Loyalty  = "loyalty"
+	Webhooks = "webhooks"
+)
+
+// defaults adalah nilai awal flag jika env tidak diisi
+// loyalty aktif secara default karena sudah berjalan sebelum ada feature flag
+var defaults = map[string]bool{
+	Tax:      false,
+	Discount: false,
+	Loyalty:  true,
+	Webhooks: false,
+}
+
+// Flags menyimpan status semua feature flag yang dibaca saat startup
+type Flags struct {
+	enabled map[string]bool
+}
+
+// Load membaca semua env FEATURE_* melalui viper
+// Flag di luar daftar defaults tetap ikut dibaca agar operator bisa menambah flag baru tanpa ubah kode
+func Load() *Flags {
+	names := make(map[string]bool)
+	for name := range defaults {
+		viper.SetDefault("FEATURE_"+strings.ToUpper(name), defaults[name])
+		names[name] = true
+	}
+	for _, env := range os.Environ() {
+		key, _, _ := strings.Cut(env, "=")
+		if name, ok := strings.CutPrefix(key, "FEATURE_"); ok && name != "" {
+			names[strings.ToLower(name)] = true
+		}
+	}
+
+	flags := &Flags{enabled: make(map[string]bool, len(names))}
+	for name := range names {
+		flags.enabled[name] = viper.GetBool("FEATURE_" + strings.ToUpper(name))
+	}
+	return flags
+}
+
+// Enabled mengembalikan true jika feature aktif, flag yang tidak dikenal dianggap nonaktif
+func (f *Flags) Enabled(name string) bool {
+	return f.enabled[name]
+}
+
+// All mengembalikan salinan status semua flag untuk ditampilkan ke frontend
+func (f *Flags) All() map[string]bool {
+	all := make(map[string]bool, len(f.enabled))
+	for name, enabled := range f.enabled {
+		all[name] = enabled
+	}
+	return all
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/database"
+	"kasir-api/services"
+	"net/http"
+)
+
+// AdminHandler menangani endpoint operasional untuk admin
+type AdminHandler struct {
+	maintenance *services.MaintenanceMode
+	// schemaCheck membandingkan schema database dengan kolom yang dipakai aplikasi
+	schemaCheck func() ([]database.SchemaMismatch, error)
+}
+
+// NewAdminHandler membuat instance baru dari AdminHandler
+func NewAdminHandler(maintenance *services.MaintenanceMode, schemaCheck func() ([]database.SchemaMismatch, error)) *AdminHandler {
+	return &AdminHandler{maintenance: maintenance, schemaCheck: schemaCheck}
+}
+
+type maintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// GET/POST /api/admin/maintenance
+// POST menerima {"enabled": true} untuk membekukan checkout, GET mengembalikan status saat ini
+func (h *AdminHandler) HandleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req maintenanceRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil || req.Enabled == nil {
+			http.Error(w, "Invalid request body, expected {\"enabled\": true|false}", http.StatusBadRequest)
+			return
+		}
+		h.maintenance.Set(*req.Enabled)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{
+		"maintenance_mode": h.maintenance.Enabled(),
+	})
+}
+
+// GET /api/admin/schema-check
+// Mengembalikan kolom yang hilang dari database, ok=false berarti migrasi belum lengkap
+func (h *AdminHandler) HandleSchemaCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mismatches, err := h.schemaCheck()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":         len(mismatches) == 0,
+		"mismatches": mismatches,
+	})
+}
@@ -10,11 +10,13 @@ import (
 )
 
 type CategoryHandler struct {
-	service *services.CategoryService
+	service       *services.CategoryService
+	reportService *services.ReportService
 }
 
-func NewCategoryHandler(service *services.CategoryService) *CategoryHandler {
-	return &CategoryHandler{service: service}
+// reportService dipakai untuk endpoint kategori yang membaca data penjualan (trend)
+func NewCategoryHandler(service *services.CategoryService, reportService *services.ReportService) *CategoryHandler {
+	return &CategoryHandler{service: service, reportService: reportService}
 }
 
 func (h *CategoryHandler) HandleCategories(w http.ResponseWriter, r *http.Request) {
@@ -59,6 +61,21 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Request) {
+	// /api/kategori/{id}/trend ditangani terpisah dari CRUD kategori
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/kategori/"), "/")
+	if idStr, action, found := strings.Cut(rest, "/"); found {
+		if action != "trend" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.GetTrend(w, r, idStr)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetByID(w, r)
@@ -82,9 +99,56 @@ func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	category, err := h.service.GetByID(id)
 	if err != nil {
-		if err.Error() == "category not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
-		} else {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+// POST /api/kategori/batch dengan body {"ids":[1,2,3]}
+func (h *CategoryHandler) GetByIDs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.CategoryBatchRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	categories, err := h.service.GetByIDs(req.IDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+// GET /api/kategori/by-name?name=Minuman
+func (h *CategoryHandler) GetByName(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	if name == "" {
+		http.Error(w, "Query param name is required", http.StatusBadRequest)
+		return
+	}
+
+	category, err := h.service.GetByName(name)
+	if err != nil {
+		if !writeNotFound(w, err) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 		return
@@ -113,9 +177,7 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	category.ID = id
 	err = h.service.Update(&category)
 	if err != nil {
-		if err.Error() == "category not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
-		} else {
+		if !writeNotFound(w, err) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 		return
@@ -135,9 +197,7 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	err = h.service.Delete(id)
 	if err != nil {
-		if err.Error() == "category not found" {
-			http.Error(w, err.Error(), http.StatusNotFound)
-		} else {
+		if !writeNotFound(w, err) {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
 		return
@@ -147,3 +207,30 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		"message": "Category deleted successfully",
 	})
 }
+
+// GetTrend menangani GET /api/kategori/{id}/trend?period=weekly&count=8
+// Mengembalikan revenue dan qty kategori untuk N periode terakhir
+func (h *CategoryHandler) GetTrend(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		return
+	}
+
+	period, count, err := parseTrendParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	trend, err := h.reportService.GetCategoryTrend(id, period, count)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trend)
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/services"
+	"net/http"
+	"strings"
+)
+
+// CustomerHandler menangani HTTP request yang berkaitan dengan pelanggan loyalty
+type CustomerHandler struct {
+	service *services.CustomerService
+}
+
+// NewCustomerHandler membuat instance baru dari CustomerHandler
+func NewCustomerHandler(service *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+// HandleCustomerByPhone menangani routing untuk endpoint /api/customers/{phone}/points
+func (h *CustomerHandler) HandleCustomerByPhone(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/customers/"), "/")
+	phone, action, _ := strings.Cut(rest, "/")
+	if phone == "" || action != "points" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.GetPoints(w, r, phone)
+}
+
+// GetPoints mengembalikan saldo poin loyalty pelanggan berdasarkan nomor HP
+func (h *CustomerHandler) GetPoints(w http.ResponseWriter, r *http.Request, phone string) {
+	customer, err := h.service.GetByPhone(phone)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"phone":  customer.Phone,
+		"name":   customer.Name,
+		"points": customer.Points,
+	})
+}
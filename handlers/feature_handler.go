@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/features"
+	"net/http"
+)
+
+// FeatureHandler menampilkan status feature flag agar frontend bisa menyesuaikan UI
+type FeatureHandler struct {
+	flags *features.Flags
+}
+
+// NewFeatureHandler membuat instance baru dari FeatureHandler
+func NewFeatureHandler(flags *features.Flags) *FeatureHandler {
+	return &FeatureHandler{flags: flags}
+}
+
+// GET /api/features
+func (h *FeatureHandler) HandleFeatures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.flags.All())
+}
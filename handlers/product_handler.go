@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"kasir-api/models"
+	"kasir-api/repositories"
 	"kasir-api/services"
 	"net/http"
 	"strconv"
@@ -11,12 +17,14 @@ import (
 
 // ProductHandler menangani HTTP request yang berkaitan dengan produk
 type ProductHandler struct {
-	service *services.ProductService
+	service            *services.ProductService
+	transactionService *services.TransactionService
 }
 
 // NewProductHandler membuat instance baru dari ProductHandler
-func NewProductHandler(service *services.ProductService) *ProductHandler {
-	return &ProductHandler{service: service}
+// transactionService dipakai untuk endpoint produk yang membaca riwayat penjualan
+func NewProductHandler(service *services.ProductService, transactionService *services.TransactionService) *ProductHandler {
+	return &ProductHandler{service: service, transactionService: transactionService}
 }
 
 // HandleProducts menangani routing untuk endpoint /api/produk
@@ -32,11 +40,29 @@ func (h *ProductHandler) HandleProducts(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-// GetAll mengambil semua data produk dari database
-// Mengembalikan array JSON berisi semua produk
+// Default dan batas ukuran halaman daftar produk
+const (
+	defaultProductPageSize = 20
+	maxProductPageSize     = 100
+)
+
+// GetAll mengambil data produk dari database per halaman
+// Query param: name atau search (filter nama), category_id, min_price dan max_price (rentang harga), sort (price_asc, name_desc, dll), page (default 1), size (default 20, maksimal 100)
+// Mengembalikan JSON {data, total, page, size}
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-	products, err := h.service.GetAll(name)
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, size, err := parsePageParams(r, defaultProductPageSize, maxProductPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, err := h.service.GetAll(filter, page, size)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -47,6 +73,122 @@ func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(products)
 }
 
+// parseProductFilter membaca query param filter daftar produk, dipakai bersama oleh list dan export
+// ?search= adalah alias ?name= agar sama dengan parameter pencarian di frontend
+func parseProductFilter(r *http.Request) (models.ProductFilter, error) {
+	query := r.URL.Query()
+	filter := models.ProductFilter{
+		Name: query.Get("name"),
+		Sort: query.Get("sort"),
+	}
+	if filter.Name == "" {
+		filter.Name = query.Get("search")
+	}
+	if _, ok := repositories.ProductSortOrders[filter.Sort]; filter.Sort != "" && !ok {
+		return filter, errors.New("Invalid sort, use id_asc, name_asc, name_desc, price_asc, price_desc, stock_asc, or stock_desc")
+	}
+
+	if categoryStr := query.Get("category_id"); categoryStr != "" {
+		categoryID, err := strconv.Atoi(categoryStr)
+		if err != nil || categoryID <= 0 {
+			return filter, errors.New("Invalid category_id, must be a positive integer")
+		}
+		filter.CategoryID = &categoryID
+	}
+
+	var err error
+	filter.MinPrice, err = parsePriceParam(r, "min_price")
+	if err != nil {
+		return filter, err
+	}
+	filter.MaxPrice, err = parsePriceParam(r, "max_price")
+	if err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errors.New("min_price must not be greater than max_price")
+	}
+	return filter, nil
+}
+
+// HandleExport menangani GET /api/produk/export
+// Mengirim katalog sebagai CSV (id,name,price,stock,category_name) dengan filter yang sama seperti daftar produk
+func (h *ProductHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="produk.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "name", "price", "stock", "category_name"})
+
+	err = h.service.Export(filter, func(p models.Product) error {
+		return writer.Write([]string{
+			strconv.Itoa(p.ID),
+			p.Name,
+			strconv.Itoa(p.Price),
+			strconv.Itoa(p.Stock),
+			p.CategoryName,
+		})
+	})
+	writer.Flush()
+	if err != nil {
+		// Header sudah terkirim, error hanya bisa dicatat di akhir file
+		writer.Write([]string{"error", err.Error()})
+		writer.Flush()
+	}
+}
+
+// parsePageParams membaca ?page= (default 1) dan ?size= (default defaultSize, dipotong ke maxSize)
+func parsePageParams(r *http.Request, defaultSize, maxSize int) (page, size int, err error) {
+	page = 1
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		p, err := strconv.Atoi(pageStr)
+		if err != nil || p <= 0 {
+			return 0, 0, errors.New("Invalid page, must be a positive integer")
+		}
+		page = p
+	}
+
+	size = defaultSize
+	if sizeStr := r.URL.Query().Get("size"); sizeStr != "" {
+		sz, err := strconv.Atoi(sizeStr)
+		if err != nil || sz <= 0 {
+			return 0, 0, errors.New("Invalid size, must be a positive integer")
+		}
+		if sz > maxSize {
+			sz = maxSize
+		}
+		size = sz
+	}
+	return page, size, nil
+}
+
+// parsePriceParam membaca query param harga opsional, nil jika tidak diisi
+func parsePriceParam(r *http.Request, name string) (*int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	price, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, must be an integer", name)
+	}
+	if price < 0 {
+		return nil, fmt.Errorf("%s must not be negative", name)
+	}
+	return &price, nil
+}
+
 // Create menambahkan produk baru ke database
 // Menerima JSON body dengan data produk (name, price, stock)
 // Mengembalikan produk yang baru dibuat dengan ID yang di-generate
@@ -60,7 +202,9 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	err = h.service.Create(&product)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		if !writeConflict(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -69,14 +213,86 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(product)
 }
 
+// HandleBulkCreate menangani POST /api/produk/bulk
+// Menerima array produk dan menyimpan semuanya dalam satu transaksi (all-or-nothing)
+func (h *ProductHandler) HandleBulkCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var products []models.Product
+	if err := json.NewDecoder(r.Body).Decode(&products); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.CreateBatch(products)
+	if err != nil {
+		if !writeConflict(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// maxImportCSVSize adalah ukuran maksimum file CSV yang boleh diupload
+const maxImportCSVSize = 10 << 20
+
+// HandleImportCSV menangani POST /api/produk/import (multipart, field "file")
+// Baris yang gagal dikembalikan di "errors" agar user bisa memperbaiki dan upload ulang baris tersebut saja
+func (h *ProductHandler) HandleImportCSV(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportCSVSize)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required (multipart field \"file\")", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	imported, rowErrors, err := h.service.ImportCSV(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"imported": imported,
+		"errors":   rowErrors,
+	})
+}
+
 // HandleProductByID menangani routing untuk endpoint /api/produk/{id}
 // Mendukung GET (ambil satu produk), PUT (update produk), dan DELETE (hapus produk)
 func (h *ProductHandler) HandleProductByID(w http.ResponseWriter, r *http.Request) {
+	// Path dengan action seperti /api/produk/{id}/write-off diteruskan ke handleProductAction
+	if idStr, action := productPathParts(r.URL.Path); action != "" {
+		// /api/produk/sku/{sku} adalah lookup barcode, bukan action pada produk
+		if idStr == "sku" {
+			h.GetBySKU(w, r, action)
+			return
+		}
+		h.handleProductAction(w, r, idStr, action)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		h.GetByID(w, r)
 	case http.MethodPut:
 		h.Update(w, r)
+	case http.MethodPatch:
+		h.PartialUpdate(w, r)
 	case http.MethodDelete:
 		h.Delete(w, r)
 	default:
@@ -84,6 +300,34 @@ func (h *ProductHandler) HandleProductByID(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// PartialUpdate menangani PATCH /api/produk/{id}
+// Body hanya berisi field yang ingin diubah, contoh {"stock": 10}; field di luar whitelist ditolak
+func (h *ProductHandler) PartialUpdate(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/produk/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	var fields map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.service.PartialUpdate(id, fields)
+	if err != nil {
+		if !writeNotFound(w, err) && !writeConflict(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
 // GetByID mengambil satu produk berdasarkan ID
 // Mengekstrak ID dari URL path dan mengembalikan detail produk
 func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
@@ -96,7 +340,29 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	product, err := h.service.GetByID(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+// GetBySKU menangani GET /api/produk/sku/{sku}
+// Dipakai integrasi scanner untuk mengubah barcode menjadi data produk
+func (h *ProductHandler) GetBySKU(w http.ResponseWriter, r *http.Request, sku string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	product, err := h.service.GetBySKU(sku)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -125,7 +391,9 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	product.ID = id
 	err = h.service.Update(&product)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		if !writeNotFound(w, err) && !writeConflict(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -145,7 +413,9 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	err = h.service.Delete(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -154,3 +424,465 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		"message": "Product deleted successfully",
 	})
 }
+
+// HandleBatchPatch menangani PATCH /api/produk/batch
+// Menerima array {id, fields} dan menerapkan semuanya dalam satu transaksi (all-or-nothing)
+func (h *ProductHandler) HandleBatchPatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var patches []models.ProductPatch
+	err := json.NewDecoder(r.Body).Decode(&patches)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	products, err := h.service.BatchPartialUpdate(patches)
+	if err != nil {
+		if !writeNotFound(w, err) && !writeConflict(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// HandleBulkMinStock menangani POST /api/produk/bulk-min-stock
+// Body berupa array [{"product_id":1,"min_stock":10}] atau satu objek {"category_id":3,"min_stock":5}
+func (h *ProductHandler) HandleBulkMinStock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var result *models.BulkMinStockResult
+	var err error
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var updates []models.MinStockUpdate
+		if err := json.Unmarshal(body, &updates); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		result, err = h.service.BulkSetMinStock(updates)
+	} else {
+		var req models.CategoryMinStockUpdate
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		result, err = h.service.SetMinStockByCategory(req)
+	}
+	if err != nil {
+		if !writeNotFound(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// HandleOpeningStock menangani POST /api/produk/opening-stock
+// Body: [{"product_id":1,"stock":100}], semua item diterapkan dalam satu transaksi
+func (h *ProductHandler) HandleOpeningStock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var items []models.OpeningStockItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	movements, err := h.service.SetOpeningStock(items)
+	if err != nil {
+		if !writeNotFound(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movements)
+}
+
+// HandleReconcileStock menangani POST /api/produk/reconcile-stock?fix=true
+// Tanpa fix hanya melaporkan selisih, dengan fix=true stok dikoreksi sesuai riwayat
+func (h *ProductHandler) HandleReconcileStock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	fix := false
+	if fixStr := r.URL.Query().Get("fix"); fixStr != "" {
+		f, err := strconv.ParseBool(fixStr)
+		if err != nil {
+			http.Error(w, "Invalid fix, use true or false", http.StatusBadRequest)
+			return
+		}
+		fix = f
+	}
+
+	result, err := h.service.ReconcileStock(fix)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// productPathParts memecah /api/produk/{id}/{action} menjadi id dan action
+// action kosong jika path hanya /api/produk/{id}
+func productPathParts(path string) (string, string) {
+	rest := strings.Trim(strings.TrimPrefix(path, "/api/produk/"), "/")
+	idStr, action, _ := strings.Cut(rest, "/")
+	return idStr, action
+}
+
+// handleProductAction menangani routing untuk endpoint /api/produk/{id}/{action}
+func (h *ProductHandler) handleProductAction(w http.ResponseWriter, r *http.Request, idStr, action string) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case action == "write-off" && r.Method == http.MethodPost:
+		h.WriteOff(w, r, id)
+	case action == "adjust-stock" && r.Method == http.MethodPost:
+		h.AdjustStock(w, r, id)
+	case action == "velocity" && r.Method == http.MethodGet:
+		h.GetSalesVelocity(w, r, id)
+	case action == "favorite" && r.Method == http.MethodPatch:
+		h.ToggleFavorite(w, r, id)
+	case action == "recent-sales" && r.Method == http.MethodGet:
+		h.GetRecentSales(w, r, id)
+	case action == "audit" && r.Method == http.MethodGet:
+		h.GetAuditTrail(w, r, id)
+	case action == "trend" && r.Method == http.MethodGet:
+		h.GetSalesTrend(w, r, id)
+	case action == "write-off", action == "adjust-stock", action == "velocity", action == "favorite", action == "recent-sales", action == "audit", action == "trend":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// WriteOff mengosongkan stok produk dan mencatat stock movement dengan alasan
+// Menerima JSON body {"reason":"spoilage"} dan mengembalikan movement yang tercatat
+func (h *ProductHandler) WriteOff(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.WriteOffRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	movement, err := h.service.WriteOff(id, req.Reason)
+	if err != nil {
+		if !writeNotFound(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+// AdjustStock menangani POST /api/produk/{id}/adjust-stock
+// Menerima JSON body {"delta": -3, "reason": "rusak"} dan mengembalikan movement beserta stok terbaru
+func (h *ProductHandler) AdjustStock(w http.ResponseWriter, r *http.Request, id int) {
+	var req models.StockAdjustRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	adjustment, err := h.service.AdjustStock(id, req.Delta, req.Reason)
+	if err != nil {
+		if !writeNotFound(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(adjustment)
+}
+
+// GetSalesVelocity mengembalikan rata-rata unit terjual per hari dalam ?days= hari terakhir (default 30)
+// beserta perkiraan berapa hari lagi stok akan habis
+func (h *ProductHandler) GetSalesVelocity(w http.ResponseWriter, r *http.Request, id int) {
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d <= 0 || d > 365 {
+			http.Error(w, "Invalid days, must be between 1 and 365", http.StatusBadRequest)
+			return
+		}
+		days = d
+	}
+
+	velocity, err := h.service.GetSalesVelocity(id, days)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(velocity)
+}
+
+// GetSalesTrend menangani GET /api/produk/{id}/trend?period=monthly&count=6
+// period: daily, weekly, atau monthly (default monthly), count: jumlah periode terakhir (default 6)
+func (h *ProductHandler) GetSalesTrend(w http.ResponseWriter, r *http.Request, id int) {
+	period, count, err := parseTrendParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	trend, err := h.service.GetSalesTrend(id, period, count)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trend)
+}
+
+// parseTrendParams membaca dan memvalidasi query period dan count untuk endpoint tren
+func parseTrendParams(r *http.Request) (string, int, error) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "monthly"
+	}
+	if _, ok := repositories.TrendPeriods[period]; !ok {
+		return "", 0, errors.New("invalid period, use daily, weekly, or monthly")
+	}
+
+	count := 6
+	if countStr := r.URL.Query().Get("count"); countStr != "" {
+		c, err := strconv.Atoi(countStr)
+		if err != nil || c <= 0 || c > 366 {
+			return "", 0, errors.New("invalid count, must be between 1 and 366")
+		}
+		count = c
+	}
+
+	return period, count, nil
+}
+
+// GetExpiring menangani GET /api/produk/expiring?days=7
+// Mengembalikan produk yang kadaluarsa dalam N hari (default 7), paling cepat kadaluarsa di awal
+func (h *ProductHandler) GetExpiring(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days := 7
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d < 0 {
+			http.Error(w, "Invalid days", http.StatusBadRequest)
+			return
+		}
+		days = d
+	}
+
+	products, err := h.service.GetExpiring(days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// ToggleFavorite menangani PATCH /api/produk/{id}/favorite
+// Membalik status favorit produk dan mengembalikan produk yang sudah diupdate
+func (h *ProductHandler) ToggleFavorite(w http.ResponseWriter, r *http.Request, id int) {
+	product, err := h.service.ToggleFavorite(id)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+// GetFavorites menangani GET /api/produk/favorites
+// Mengembalikan daftar produk favorit untuk bar akses cepat di layar POS
+func (h *ProductHandler) GetFavorites(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	products, err := h.service.GetFavorites()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// GetStockStatus menangani POST /api/produk/stock-status
+// Body: {"ids": [1, 2, 3]}, ids kosong atau body kosong berarti semua produk
+func (h *ProductHandler) GetStockStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.StockStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	levels, err := h.service.GetStockStatus(req.IDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(levels)
+}
+
+// GetLowStock menangani GET /api/produk/low-stock?threshold=10
+// Mengembalikan produk dengan stok <= threshold (default 5), stok paling sedikit di awal
+func (h *ProductHandler) GetLowStock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	threshold := 5
+	if thresholdStr := r.URL.Query().Get("threshold"); thresholdStr != "" {
+		t, err := strconv.Atoi(thresholdStr)
+		if err != nil || t < 0 {
+			http.Error(w, "Invalid threshold", http.StatusBadRequest)
+			return
+		}
+		threshold = t
+	}
+
+	products, err := h.service.GetLowStock(threshold)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// GetOutOfStock menangani GET /api/produk/out-of-stock
+// Mengembalikan produk dengan stok habis untuk indikator "tidak tersedia" di layar POS
+func (h *ProductHandler) GetOutOfStock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	products, err := h.service.GetOutOfStock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+// GetGrouped menangani GET /api/produk/grouped
+// Mengembalikan produk yang dikelompokkan per kategori untuk tampilan menu
+func (h *ProductHandler) GetGrouped(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	groups, err := h.service.GetGrouped()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+// GetRecentSales menangani GET /api/produk/{id}/recent-sales?limit=10
+// Mengembalikan transaksi terakhir yang berisi produk ini, array kosong jika belum pernah terjual
+func (h *ProductHandler) GetRecentSales(w http.ResponseWriter, r *http.Request, id int) {
+	limit := 10
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l <= 0 || l > 100 {
+			http.Error(w, "Invalid limit, must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	sales, err := h.transactionService.GetRecentForProduct(id, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sales)
+}
+
+// GetAuditTrail menangani GET /api/produk/{id}/audit
+// Mengembalikan timeline semua perubahan produk (create, update, stok, favorit, hapus) dari yang terlama
+func (h *ProductHandler) GetAuditTrail(w http.ResponseWriter, r *http.Request, id int) {
+	audits, err := h.service.GetAuditTrail(id)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(audits)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"kasir-api/models"
+	"kasir-api/services"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PromoHandler menangani HTTP request yang berkaitan dengan promo set
+type PromoHandler struct {
+	service *services.PromoService
+}
+
+// NewPromoHandler membuat instance baru dari PromoHandler
+func NewPromoHandler(service *services.PromoService) *PromoHandler {
+	return &PromoHandler{service: service}
+}
+
+// HandlePromo menangani routing untuk /api/promo/snapshot dan /api/promo/{name}/apply
+// Path dibaca dalam bentuk escaped agar nama promo berisi "/" atau "%" di-decode tepat satu kali
+func (h *PromoHandler) HandlePromo(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), "/api/promo/"), "/")
+
+	if rest == "snapshot" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.Snapshot(w, r)
+		return
+	}
+
+	name, action, _ := strings.Cut(rest, "/")
+	if name == "" || action != "apply" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, err := url.PathUnescape(name)
+	if err != nil {
+		http.Error(w, "Invalid promo name", http.StatusBadRequest)
+		return
+	}
+	h.Apply(w, r, name)
+}
+
+// Snapshot menangani POST /api/promo/snapshot
+// Body: {"name": "lebaran"}
+func (h *PromoHandler) Snapshot(w http.ResponseWriter, r *http.Request) {
+	var req models.PromoSnapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.Snapshot(req.Name)
+	if err != nil {
+		if !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// Apply menangani POST /api/promo/{name}/apply
+func (h *PromoHandler) Apply(w http.ResponseWriter, r *http.Request, name string) {
+	result, err := h.service.Apply(name)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"kasir-api/models"
 	"kasir-api/services"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type ReportHandler struct {
@@ -15,24 +20,102 @@ func NewReportHandler(service *services.ReportService) *ReportHandler {
 	return &ReportHandler{service: service}
 }
 
-// GET /api/report/hari-ini
+// GET /api/report/hari-ini?top=5&format=csv&tz=Asia/Jakarta
 func (h *ReportHandler) HandleTodayReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	report, err := h.service.GetTodayReport()
+	top, err := parseTopParam(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, err := parseFormatParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetTodayReport(top, r.URL.Query().Get("tz"))
+	if err != nil {
+		if !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
+	if format == "csv" {
+		writeReportCSV(w, "laporan-hari-ini.csv", report)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(report)
 }
 
-// GET /api/report?start_date=2026-01-01&end_date=2026-02-01
+// parseFormatParam membaca ?format= (json atau csv), default json agar client lama tidak berubah
+func parseFormatParam(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		return "json", nil
+	case "csv":
+		return format, nil
+	default:
+		return "", errors.New("Invalid format, use json or csv")
+	}
+}
+
+// writeReportCSV mengirim ringkasan report sebagai CSV dengan satu baris per metrik
+// Produk terlaris ditulis sebagai produk_terlaris_N (nama) dan produk_terlaris_N_qty
+func writeReportCSV(w http.ResponseWriter, filename string, report *models.ReportResponse) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"metric", "value"})
+	writer.Write([]string{"total_revenue", strconv.Itoa(report.TotalRevenue)})
+	writer.Write([]string{"total_transaksi", strconv.Itoa(report.TotalTransaksi)})
+	for i, p := range report.ProdukTerlaris {
+		key := "produk_terlaris_" + strconv.Itoa(i+1)
+		writer.Write([]string{key, p.Nama})
+		writer.Write([]string{key + "_qty", strconv.Itoa(p.QtyTerjual)})
+	}
+	writer.Flush()
+}
+
+// writeDailyReportCSV mengirim breakdown harian sebagai CSV dengan satu baris per hari
+func writeDailyReportCSV(w http.ResponseWriter, filename string, days []models.DailyReport) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"tanggal", "total_revenue", "total_transaksi"})
+	for _, d := range days {
+		writer.Write([]string{d.Tanggal, strconv.Itoa(d.TotalRevenue), strconv.Itoa(d.TotalTransaksi)})
+	}
+	writer.Flush()
+}
+
+// parseTopParam membaca ?top= (jumlah produk terlaris), default 5 dan dipotong ke maksimal 20
+func parseTopParam(r *http.Request) (int, error) {
+	topStr := r.URL.Query().Get("top")
+	if topStr == "" {
+		return services.DefaultTopProducts, nil
+	}
+	top, err := strconv.Atoi(topStr)
+	if err != nil || top <= 0 {
+		return 0, errors.New("Invalid top, must be a positive integer")
+	}
+	if top > services.MaxTopProducts {
+		top = services.MaxTopProducts
+	}
+	return top, nil
+}
+
+// GET /api/report?start_date=2026-01-01&end_date=2026-02-01&top=5&format=csv&tz=Asia/Jakarta
+// format=csv dengan detailed=true mengirim breakdown harian (satu baris per hari)
+// tz menentukan tanggal transaksi, default APP_TIMEZONE
 func (h *ReportHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
@@ -47,12 +130,48 @@ func (h *ReportHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 
 	startDate := r.URL.Query().Get("start_date")
 	endDate := r.URL.Query().Get("end_date")
+	tz := r.URL.Query().Get("tz")
+
+	top, err := parseTopParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, err := parseFormatParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Jika tidak ada query params, return today's report
 	if startDate == "" || endDate == "" {
-		report, err := h.service.GetTodayReport()
+		report, err := h.service.GetTodayReport(top, tz)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			if !writeValidationError(w, err) {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		if format == "csv" {
+			writeReportCSV(w, "laporan-hari-ini.csv", report)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	// detailed=true menambahkan revenue harian dan top produk dalam satu response
+	if r.URL.Query().Get("detailed") == "true" {
+		report, err := h.service.GetDetailedReport(startDate, endDate, top, tz)
+		if err != nil {
+			if !writeValidationError(w, err) {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+		if format == "csv" {
+			writeDailyReportCSV(w, "laporan-harian-"+startDate+"-"+endDate+".csv", report.DailyRevenue)
 			return
 		}
 		w.Header().Set("Content-Type", "application/json")
@@ -60,7 +179,580 @@ func (h *ReportHandler) HandleReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	report, err := h.service.GetReportByDateRange(startDate, endDate)
+	report, err := h.service.GetReportByDateRange(startDate, endDate, top, tz)
+	if err != nil {
+		if !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if format == "csv" {
+		writeReportCSV(w, "laporan-"+startDate+"-"+endDate+".csv", report)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// parseDateRange membaca dan memvalidasi query param start_date & end_date (format YYYY-MM-DD)
+func parseDateRange(r *http.Request) (string, string, error) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+	if err := validateDateRange(startDate, endDate); err != nil {
+		return "", "", err
+	}
+
+	return startDate, endDate, nil
+}
+
+// validateDateRange memastikan start_date dan end_date terisi, berformat YYYY-MM-DD, dan start <= end
+// Dipakai juga oleh endpoint yang menerima range tanggal lewat body JSON
+func validateDateRange(startDate, endDate string) error {
+	if startDate == "" || endDate == "" {
+		return errors.New("start_date and end_date are required")
+	}
+
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return errors.New("invalid start_date, use YYYY-MM-DD")
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return errors.New("invalid end_date, use YYYY-MM-DD")
+	}
+	if start.After(end) {
+		return errors.New("start_date must not be after end_date")
+	}
+
+	return nil
+}
+
+// GET /api/report/ticket-distribution?start_date=2026-01-01&end_date=2026-02-01&boundaries=10000,50000,100000
+func (h *ReportHandler) HandleTicketDistribution(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var boundaries []int
+	if raw := r.URL.Query().Get("boundaries"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			b, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				http.Error(w, "Invalid boundaries, use comma separated numbers", http.StatusBadRequest)
+				return
+			}
+			boundaries = append(boundaries, b)
+		}
+	}
+
+	buckets, err := h.service.GetTicketDistribution(startDate, endDate, boundaries)
+	if err != nil {
+		// Error validasi boundaries dikembalikan sebelum query ke database
+		if !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buckets)
+}
+
+// GET /api/report/throughput?start_date=2026-01-01&end_date=2026-02-01
+func (h *ReportHandler) HandleThroughput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetThroughput(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/basket-size?start_date=2026-01-01&end_date=2026-02-01
+func (h *ReportHandler) HandleBasketSize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetBasketSize(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/closing?date=2026-01-01 (default hari ini menurut APP_TIMEZONE)
+func (h *ReportHandler) HandleDailyClosing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = h.service.Today()
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		http.Error(w, "invalid date, use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetDailyClosing(date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET/POST /api/report/snapshot?date=2026-01-01 (default hari ini menurut APP_TIMEZONE)
+// POST menghitung dan menyimpan (atau menimpa) snapshot tanggal tersebut, GET membaca snapshot yang tersimpan
+func (h *ReportHandler) HandleDailySnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = h.service.Today()
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		http.Error(w, "invalid date, use YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	var snapshot *models.DailySnapshot
+	var err error
+	if r.Method == http.MethodPost {
+		snapshot, err = h.service.CreateDailySnapshot(date)
+	} else {
+		snapshot, err = h.service.GetDailySnapshot(date)
+	}
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// GET /api/report/reorder-by-kategori
+func (h *ReportHandler) HandleReorderByCategory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := h.service.GetReorderByCategory()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/transaction-interval?start_date=2026-01-01&end_date=2026-02-01
+func (h *ReportHandler) HandleTransactionInterval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetTransactionInterval(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/daily?start_date=2026-01-01&end_date=2026-01-31&format=csv&tz=Asia/Jakarta
+// Revenue dan jumlah transaksi per hari, hari tanpa transaksi bernilai 0
+func (h *ReportHandler) HandleDailyBreakdown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, err := parseFormatParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetDailyBreakdown(startDate, endDate, r.URL.Query().Get("tz"))
+	if err != nil {
+		if !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if format == "csv" {
+		writeDailyReportCSV(w, "laporan-harian-"+startDate+"-"+endDate+".csv", report)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/hourly-pattern?start_date=2026-01-01&end_date=2026-02-01
+func (h *ReportHandler) HandleHourlyPattern(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetHourlyPattern(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// POST /api/report/dates dengan body {"dates":["2026-01-03","2026-01-10"]}
+func (h *ReportHandler) HandleReportForDates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.ReportDatesRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	for _, d := range req.Dates {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			http.Error(w, "invalid date "+d+", use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(req.Dates) == 0 || len(req.Dates) > services.MaxReportDates {
+		http.Error(w, "dates must contain between 1 and "+strconv.Itoa(services.MaxReportDates)+" items", http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetReportForDates(req.Dates)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/pareto?start_date=2026-01-01&end_date=2026-02-01
+func (h *ReportHandler) HandlePareto(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetParetoReport(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/anomalies?start_date=2026-01-01&end_date=2026-02-01&multiplier=5
+func (h *ReportHandler) HandleAnomalies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// multiplier opsional, jika kosong service memakai ANOMALY_MULTIPLIER
+	var multiplier float64
+	if raw := r.URL.Query().Get("multiplier"); raw != "" {
+		multiplier, err = strconv.ParseFloat(raw, 64)
+		if err != nil || multiplier <= 0 {
+			http.Error(w, "Invalid multiplier, must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.service.GetAnomalies(startDate, endDate, multiplier)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/category-attach-rate?start_date=2026-01-01&end_date=2026-02-01
+func (h *ReportHandler) HandleCategoryAttachRate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetCategoryAttachRate(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/kpi?start_date=2026-01-01&end_date=2026-02-01
+func (h *ReportHandler) HandleKPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetKPIReport(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/customer-mix?start_date=2026-01-01&end_date=2026-02-01
+func (h *ReportHandler) HandleCustomerMix(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetCustomerMix(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/stock-cover?days=30&min_days=7
+// days: periode rata-rata penjualan (default 30), min_days: batas hari tersisa yang ditandai (default 7)
+func (h *ReportHandler) HandleStockCover(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d <= 0 || d > 365 {
+			http.Error(w, "Invalid days, must be between 1 and 365", http.StatusBadRequest)
+			return
+		}
+		days = d
+	}
+
+	minDays := 7.0
+	if minDaysStr := r.URL.Query().Get("min_days"); minDaysStr != "" {
+		m, err := strconv.ParseFloat(minDaysStr, 64)
+		if err != nil || m < 0 {
+			http.Error(w, "Invalid min_days", http.StatusBadRequest)
+			return
+		}
+		minDays = m
+	}
+
+	report, err := h.service.GetStockCover(days, minDays)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/profit?start_date=2026-01-01&end_date=2026-02-01
+// Laba kotor dari item terjual, item tanpa harga modal dilaporkan terpisah
+func (h *ReportHandler) HandleProfit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetProfitReport(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/kategori?start_date=2026-01-01&end_date=2026-02-01
+func (h *ReportHandler) HandleRevenueByCategory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetRevenueByCategory(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/discounts?start_date=2026-01-01&end_date=2026-02-01
+// Ringkasan diskon item, diskon transaksi, dan diskon loyalty dalam range
+func (h *ReportHandler) HandleDiscounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetDiscountReport(startDate, endDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GET /api/report/tax?start_date=2026-01-01&end_date=2026-02-01
+// Pajak terkumpul beserta subtotal kena pajak dan bebas pajak, bernilai 0 jika fitur pajak dimatikan
+func (h *ReportHandler) HandleTax(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.service.GetTaxReport(startDate, endDate)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
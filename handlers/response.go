@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"kasir-api/repositories"
+	"kasir-api/services"
+	"net/http"
+)
+
+// notFoundResponse adalah body JSON untuk response 404
+// ID dikosongkan jika pencarian tidak berdasarkan ID (misal berdasarkan nama)
+type notFoundResponse struct {
+	Error    string `json:"error"`
+	Resource string `json:"resource"`
+	ID       int    `json:"id,omitempty"`
+}
+
+// writeNotFound menulis response 404 terstruktur jika err adalah NotFoundError
+// Mengembalikan false jika err bukan NotFoundError agar caller bisa menangani error lain
+func writeNotFound(w http.ResponseWriter, err error) bool {
+	var notFound *repositories.NotFoundError
+	if !errors.As(err, &notFound) {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(notFoundResponse{
+		Error:    notFound.Error(),
+		Resource: notFound.Resource,
+		ID:       notFound.ID,
+	})
+	return true
+}
+
+// conflictResponse adalah body JSON untuk response 409
+type conflictResponse struct {
+	Error    string `json:"error"`
+	Resource string `json:"resource"`
+	Field    string `json:"field"`
+	Value    string `json:"value"`
+}
+
+// writeConflict menulis response 409 terstruktur jika err adalah ConflictError
+// Mengembalikan false jika err bukan ConflictError agar caller bisa menangani error lain
+func writeConflict(w http.ResponseWriter, err error) bool {
+	var conflict *repositories.ConflictError
+	if !errors.As(err, &conflict) {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(conflictResponse{
+		Error:    conflict.Error(),
+		Resource: conflict.Resource,
+		Field:    conflict.Field,
+		Value:    conflict.Value,
+	})
+	return true
+}
+
+// writeValidationError menulis response 400 jika err adalah services.ErrValidation
+// Mengembalikan false untuk error lain (misal error database) agar caller bisa membalas 500
+func writeValidationError(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, services.ErrValidation) {
+		return false
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+	return true
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"kasir-api/services"
+	"net/http"
+	"time"
+)
+
+// StockAlertHandler menangani stream alert stok menipis via Server-Sent Events
+type StockAlertHandler struct {
+	broker *services.StockAlertBroker
+}
+
+// NewStockAlertHandler membuat instance baru dari StockAlertHandler
+func NewStockAlertHandler(broker *services.StockAlertBroker) *StockAlertHandler {
+	return &StockAlertHandler{broker: broker}
+}
+
+// HandleLowStockStream menangani GET /api/produk/low-stock/stream
+// Setiap kali checkout membuat stok produk <= min_stock, event "low-stock" dikirim berisi data produk
+func (h *StockAlertHandler) HandleLowStockStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	alerts := h.broker.Subscribe()
+	defer h.broker.Unsubscribe(alerts)
+
+	// Comment berkala agar proxy/load balancer tidak memutus koneksi yang idle
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			// Client menutup koneksi
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case product := <-alerts:
+			data, err := json.Marshal(product)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: low-stock\ndata: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
@@ -1,20 +1,38 @@
 package handlers
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"kasir-api/models"
+	"kasir-api/repositories"
 	"kasir-api/services"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // TransactionHandler menangani HTTP request yang berkaitan dengan transaksi
 type TransactionHandler struct {
 	service *services.TransactionService
+	// checkoutSlots adalah semaphore untuk membatasi jumlah checkout yang berjalan bersamaan
+	// agar connection pool database tidak habis saat ramai (flash sale)
+	checkoutSlots chan struct{}
+	maintenance   *services.MaintenanceMode
 }
 
 // NewTransactionHandler membuat instance baru dari TransactionHandler
-func NewTransactionHandler(service *services.TransactionService) *TransactionHandler {
-	return &TransactionHandler{service: service}
+// maxConcurrentCheckouts menentukan berapa checkout yang boleh diproses bersamaan
+func NewTransactionHandler(service *services.TransactionService, maxConcurrentCheckouts int, maintenance *services.MaintenanceMode) *TransactionHandler {
+	if maxConcurrentCheckouts <= 0 {
+		maxConcurrentCheckouts = 1
+	}
+	return &TransactionHandler{
+		service:       service,
+		checkoutSlots: make(chan struct{}, maxConcurrentCheckouts),
+		maintenance:   maintenance,
+	}
 }
 
 //multiple item and quantity
@@ -29,6 +47,22 @@ func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Reque
 }
 
 func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	// Saat maintenance (rekonsiliasi kas, update), penjualan dibekukan sementara
+	if h.maintenance.Enabled() {
+		http.Error(w, "Checkout is temporarily disabled for maintenance", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Ambil slot checkout, jika penuh langsung tolak daripada mengantri dan menahan koneksi
+	select {
+	case h.checkoutSlots <- struct{}{}:
+		defer func() { <-h.checkoutSlots }()
+	default:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Too many checkouts in progress, please retry", http.StatusServiceUnavailable)
+		return
+	}
+
 	var req models.CheckoutRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
@@ -36,9 +70,32 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items)
+	// Header Idempotency-Key dipakai aplikasi kasir mobile yang mengulang request saat koneksi putus
+	// Jika header dan idempotency_key di body sama-sama diisi, keduanya harus sama
+	if key := strings.TrimSpace(r.Header.Get("Idempotency-Key")); key != "" {
+		if body := strings.TrimSpace(req.IdempotencyKey); body != "" && body != key {
+			http.Error(w, "Idempotency-Key header does not match idempotency_key in body", http.StatusBadRequest)
+			return
+		}
+		req.IdempotencyKey = key
+	}
+
+	transaction, err := h.service.Checkout(&req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		// Input tidak valid (services.ErrValidation) dan stok tidak cukup (InsufficientStockError) dibalas 400,
+		// selain itu (koneksi putus, deadlock, commit gagal) adalah error server
+		var insufficient *repositories.InsufficientStockError
+		if errors.As(err, &insufficient) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, services.ErrDiscountLimit) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if !writeNotFound(w, err) && !writeValidationError(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -46,3 +103,302 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(transaction)
 }
+
+// maxBatchCheckout adalah jumlah entri maksimum dalam satu batch checkout
+const maxBatchCheckout = 500
+
+// BatchCheckout menangani POST /api/checkout/batch
+// Body: array checkout, masing-masing dengan idempotency_key. Response berisi hasil per entri sesuai urutan
+func (h *TransactionHandler) BatchCheckout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if h.maintenance.Enabled() {
+		http.Error(w, "Checkout is temporarily disabled for maintenance", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Satu batch memakai satu slot checkout karena entri diproses berurutan
+	select {
+	case h.checkoutSlots <- struct{}{}:
+		defer func() { <-h.checkoutSlots }()
+	default:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Too many checkouts in progress, please retry", http.StatusServiceUnavailable)
+		return
+	}
+
+	var reqs []models.CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(reqs) == 0 {
+		http.Error(w, "batch must not be empty", http.StatusBadRequest)
+		return
+	}
+	if len(reqs) > maxBatchCheckout {
+		http.Error(w, "batch must not contain more than "+strconv.Itoa(maxBatchCheckout)+" checkouts", http.StatusBadRequest)
+		return
+	}
+
+	results := h.service.BatchCheckout(reqs)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// Default dan batas ukuran halaman riwayat transaksi
+const (
+	defaultTransactionPageSize = 20
+	maxTransactionPageSize     = 100
+)
+
+// HandleTransactions menangani GET /api/transaksi?page=1&size=20&start_date=2026-01-01&end_date=2026-01-31
+// start_date dan end_date opsional tetapi harus diisi berdua; tanpa keduanya semua transaksi ditampilkan
+// Mengembalikan riwayat transaksi dari yang terbaru dalam bentuk {data, total, page, size}
+func (h *TransactionHandler) HandleTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	page, size, err := parsePageParams(r, defaultTransactionPageSize, maxTransactionPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var filter models.TransactionFilter
+	if r.URL.Query().Get("start_date") != "" || r.URL.Query().Get("end_date") != "" {
+		filter.StartDate, filter.EndDate, err = parseDateRange(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	transactions, err := h.service.GetAll(filter, page, size)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transactions)
+}
+
+// HandleTransactionByID menangani routing untuk endpoint /api/transaksi/{id}, /{id}/cancel, dan /{id}/receipt
+func (h *TransactionHandler) HandleTransactionByID(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/transaksi/"), "/")
+	if idStr, action, found := strings.Cut(rest, "/"); found {
+		switch {
+		case action == "cancel" && r.Method == http.MethodPost:
+			h.Cancel(w, r, idStr)
+		case action == "receipt" && r.Method == http.MethodGet:
+			h.GetReceipt(w, r, idStr)
+		case action == "cancel", action == "receipt":
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetByID(w, r)
+	default:
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// GetByID menangani GET /api/transaksi/{id}
+// Mengembalikan transaksi beserta detailnya, 404 jika transaksi tidak ada
+func (h *TransactionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/transaksi/"), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.GetByID(id)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// Cancel menangani POST /api/transaksi/{id}/cancel
+// Stok dan poin loyalty dikembalikan; transaksi yang sudah dibatalkan atau di-void dibalas 409
+func (h *TransactionHandler) Cancel(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := h.service.Cancel(id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrTransactionCancelled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// GetReceipt menangani GET /api/transaksi/{id}/receipt
+// Mengembalikan struk teks lebar tetap untuk printer thermal
+func (h *TransactionHandler) GetReceipt(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := h.service.GenerateReceipt(id)
+	if err != nil {
+		if !writeNotFound(w, err) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(receipt))
+}
+
+// Batas jumlah transaksi per halaman untuk cursor pagination
+const (
+	defaultCursorLimit = 100
+	maxCursorLimit     = 1000
+)
+
+// ListByCursor menangani GET /api/transaksi/cursor?cursor=12345&limit=100
+// Dipakai tool backup untuk membaca seluruh transaksi tanpa OFFSET yang lambat di tabel besar
+func (h *TransactionHandler) ListByCursor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cursor := 0
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		c, err := strconv.Atoi(v)
+		if err != nil || c <= 0 {
+			http.Error(w, "cursor must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		cursor = c
+	}
+
+	limit := defaultCursorLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		if l > maxCursorLimit {
+			l = maxCursorLimit
+		}
+		limit = l
+	}
+
+	page, err := h.service.GetPageByCursor(cursor, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// ExportDetails menangani GET /api/transaksi/export-details?start_date=2026-01-01&end_date=2026-02-01
+// Mengirim CSV satu baris per item transaksi untuk keperluan pembukuan
+func (h *TransactionHandler) ExportDetails(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	startDate, endDate, err := parseDateRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="transaksi-detail.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"transaction_id", "date", "payment_method", "product_name", "quantity", "unit_price", "subtotal"})
+
+	err = h.service.StreamDetails(startDate, endDate, func(d models.TransactionDetailExport) error {
+		return writer.Write([]string{
+			strconv.Itoa(d.TransactionID),
+			d.CreatedAt.Format(time.RFC3339),
+			d.PaymentMethod,
+			d.ProductName,
+			strconv.Itoa(d.Quantity),
+			strconv.Itoa(d.UnitPrice),
+			strconv.Itoa(d.Subtotal),
+		})
+	})
+	writer.Flush()
+	if err != nil {
+		// Header sudah terkirim, error hanya bisa dicatat di akhir file
+		writer.Write([]string{"error", err.Error()})
+		writer.Flush()
+	}
+}
+
+// VoidBatch menangani POST /api/transaksi/void-batch
+// Body: {"start_date":"2026-01-01","end_date":"2026-01-01","confirm":true}
+func (h *TransactionHandler) VoidBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.VoidBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := validateDateRange(req.StartDate, req.EndDate); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !req.Confirm {
+		http.Error(w, "confirm must be true to void transactions", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.service.VoidByDateRange(req.StartDate, req.EndDate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"kasir-api/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Saat semua slot checkout terpakai, checkout berikutnya langsung ditolak 503 dengan Retry-After
+// Setelah satu slot dilepas, checkout berikutnya diproses lagi (body tidak valid sehingga berhenti di 400 tanpa menyentuh database)
+func TestCheckoutConcurrencyLimit(t *testing.T) {
+	const slots = 2
+	h := NewTransactionHandler(nil, slots, services.NewMaintenanceMode(false))
+
+	for i := 0; i < slots; i++ {
+		h.checkoutSlots <- struct{}{}
+	}
+
+	rec := httptest.NewRecorder()
+	h.Checkout(rec, httptest.NewRequest(http.MethodPost, "/api/checkout", strings.NewReader("not json")))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got == "" {
+		t.Fatal("Retry-After header not set")
+	}
+
+	<-h.checkoutSlots
+
+	rec = httptest.NewRecorder()
+	h.Checkout(rec, httptest.NewRequest(http.MethodPost, "/api/checkout", strings.NewReader("not json")))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status after releasing a slot = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	// Slot yang dipakai request tadi harus dikembalikan setelah handler selesai
+	if len(h.checkoutSlots) != slots-1 {
+		t.Fatalf("slots in use = %d, want %d", len(h.checkoutSlots), slots-1)
+	}
+}
@@ -4,19 +4,32 @@ import (
 	"encoding/json"
 	"fmt"
 	"kasir-api/database"
+	"kasir-api/features"
 	"kasir-api/handlers"
+	"kasir-api/models"
 	"kasir-api/repositories"
 	"kasir-api/services"
 	"net/http"
 	"os"
 	"strings"
+	"time"
+	_ "time/tzdata" // Container Railway tidak selalu punya zoneinfo untuk validasi APP_TIMEZONE / ?tz=
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Port   string `mapstructure:"PORT"`
-	DBConn string `mapstructure:"DB_CONN"`
+	Port                   string  `mapstructure:"PORT"`
+	DBConn                 string  `mapstructure:"DB_CONN"`
+	MaxConcurrentCheckouts int     `mapstructure:"MAX_CONCURRENT_CHECKOUTS"`
+	AdminToken             string  `mapstructure:"ADMIN_TOKEN"`
+	MaintenanceMode        bool    `mapstructure:"MAINTENANCE_MODE"`
+	LoyaltySpendPerPoint   int     `mapstructure:"LOYALTY_SPEND_PER_POINT"`
+	LoyaltyPointValue      int     `mapstructure:"LOYALTY_POINT_VALUE"`
+	AnomalyMultiplier      float64 `mapstructure:"ANOMALY_MULTIPLIER"`
+	StoreName              string  `mapstructure:"STORE_NAME"`
+	AppTimezone            string  `mapstructure:"APP_TIMEZONE"`
+	MaxDiscountPercent     float64 `mapstructure:"MAX_DISCOUNT_PERCENT"`
 }
 
 func main() {
@@ -30,15 +43,54 @@ func main() {
 		_ = viper.ReadInConfig()
 	}
 
+	// Default 10 agar masih menyisakan koneksi pool (max 25) untuk endpoint lain
+	viper.SetDefault("MAX_CONCURRENT_CHECKOUTS", 10)
+	// Default: 1 poin setiap belanja Rp10.000, 1 poin bernilai Rp100 saat ditukar
+	viper.SetDefault("LOYALTY_SPEND_PER_POINT", 10000)
+	viper.SetDefault("LOYALTY_POINT_VALUE", 100)
+	// Transaksi > 5x rata-rata dianggap anomali
+	viper.SetDefault("ANOMALY_MULTIPLIER", 5)
+	// Nama toko di header struk
+	viper.SetDefault("STORE_NAME", "Kasir")
+	// Timezone toko untuk menentukan "hari ini" dan tanggal transaksi di report
+	viper.SetDefault("APP_TIMEZONE", "Asia/Jakarta")
+	// Batas diskon item dan transaksi dalam persen, 100 berarti tidak dibatasi
+	viper.SetDefault("MAX_DISCOUNT_PERCENT", 100)
+
 	config := Config{
-		Port:   viper.GetString("PORT"),
-		DBConn: viper.GetString("DB_CONN"),
+		Port:                   viper.GetString("PORT"),
+		DBConn:                 viper.GetString("DB_CONN"),
+		MaxConcurrentCheckouts: viper.GetInt("MAX_CONCURRENT_CHECKOUTS"),
+		AdminToken:             viper.GetString("ADMIN_TOKEN"),
+		MaintenanceMode:        viper.GetBool("MAINTENANCE_MODE"),
+		LoyaltySpendPerPoint:   viper.GetInt("LOYALTY_SPEND_PER_POINT"),
+		LoyaltyPointValue:      viper.GetInt("LOYALTY_POINT_VALUE"),
+		AnomalyMultiplier:      viper.GetFloat64("ANOMALY_MULTIPLIER"),
+		StoreName:              viper.GetString("STORE_NAME"),
+		AppTimezone:            viper.GetString("APP_TIMEZONE"),
+		MaxDiscountPercent:     viper.GetFloat64("MAX_DISCOUNT_PERCENT"),
 	}
 
 	// Log config untuk debugging (jangan log password di production)
 	fmt.Println("=== Configuration ===")
 	fmt.Println("PORT:", config.Port)
 	fmt.Println("DB_CONN exists:", config.DBConn != "")
+	fmt.Println("MAX_CONCURRENT_CHECKOUTS:", config.MaxConcurrentCheckouts)
+	fmt.Println("ADMIN_TOKEN exists:", config.AdminToken != "")
+	fmt.Println("MAINTENANCE_MODE:", config.MaintenanceMode)
+	fmt.Println("APP_TIMEZONE:", config.AppTimezone)
+	if _, err := time.LoadLocation(config.AppTimezone); err != nil {
+		fmt.Println("ERROR: Invalid APP_TIMEZONE:", err)
+		panic(err)
+	}
+	fmt.Println("MAX_DISCOUNT_PERCENT:", config.MaxDiscountPercent)
+	if config.MaxDiscountPercent < 0 || config.MaxDiscountPercent > 100 {
+		err := fmt.Errorf("MAX_DISCOUNT_PERCENT must be between 0 and 100, got %g", config.MaxDiscountPercent)
+		fmt.Println("ERROR: Invalid MAX_DISCOUNT_PERCENT:", err)
+		panic(err)
+	}
+	flags := features.Load()
+	fmt.Println("FEATURES:", flags.All())
 	fmt.Println("=====================")
 
 	// 1. Inisialisasi database terlebih dahulu
@@ -53,34 +105,119 @@ func main() {
 	defer db.Close()
 	fmt.Println("Database connected successfully!")
 
+	err = database.Migrate(db)
+	if err != nil {
+		fmt.Println("ERROR: Failed to run database migrations:", err)
+		panic(err)
+	}
+	database.LogSchemaCheck(db)
+
 	// 2. Inisialisasi layer-layer aplikasi (Repository -> Service -> Handler)
+	stockAlerts := services.NewStockAlertBroker()
+	stockAlertHandler := handlers.NewStockAlertHandler(stockAlerts)
+
+	transactionRepo := repositories.NewTransactionRepository(db)
+	transactionService := services.NewTransactionService(transactionRepo, models.LoyaltyConfig{
+		SpendPerPoint: config.LoyaltySpendPerPoint,
+		PointValue:    config.LoyaltyPointValue,
+	}, stockAlerts, flags, config.StoreName, config.AppTimezone, config.MaxDiscountPercent)
+
 	productRepo := repositories.NewProductRepository(db)
-	productService := services.NewProductService(productRepo)
-	productHandler := handlers.NewProductHandler(productService)
+	productService := services.NewProductService(productRepo, config.AppTimezone)
+	productHandler := handlers.NewProductHandler(productService, transactionService)
+
+	reportRepo := repositories.NewReportRepository(db)
+	reportService := services.NewReportService(reportRepo, config.AnomalyMultiplier, config.AppTimezone, config.MaxDiscountPercent, flags)
+	reportHandler := handlers.NewReportHandler(reportService)
 
 	categoryRepo := repositories.NewCategoryRepository(db)
 	categoryService := services.NewCategoryService(categoryRepo)
-	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService, reportService)
 
-	transactionRepo := repositories.NewTransactionRepository(db)
-	transactionService := services.NewTransactionService(transactionRepo)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	maintenance := services.NewMaintenanceMode(config.MaintenanceMode)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, config.MaxConcurrentCheckouts, maintenance)
 
-	reportRepo := repositories.NewReportRepository(db)
-	reportService := services.NewReportService(reportRepo)
-	reportHandler := handlers.NewReportHandler(reportService)
+	customerRepo := repositories.NewCustomerRepository(db)
+	customerService := services.NewCustomerService(customerRepo)
+	customerHandler := handlers.NewCustomerHandler(customerService)
+
+	importRepo := repositories.NewImportRepository(db)
+	importService := services.NewImportService(importRepo)
+	importHandler := handlers.NewImportHandler(importService)
+
+	promoRepo := repositories.NewPromoRepository(db)
+	promoService := services.NewPromoService(promoRepo)
+	promoHandler := handlers.NewPromoHandler(promoService)
+
+	adminHandler := handlers.NewAdminHandler(maintenance, func() ([]database.SchemaMismatch, error) {
+		return database.CheckSchema(db)
+	})
+	featureHandler := handlers.NewFeatureHandler(flags)
 
 	// 3. Register routes
 	http.HandleFunc("/api/produk", productHandler.HandleProducts)
 	http.HandleFunc("/api/produk/", productHandler.HandleProductByID)
+	http.HandleFunc("/api/produk/batch", productHandler.HandleBatchPatch)
+	http.HandleFunc("/api/produk/bulk", productHandler.HandleBulkCreate)
+	http.HandleFunc("/api/produk/import", productHandler.HandleImportCSV)
+	http.HandleFunc("/api/produk/export", productHandler.HandleExport)
+	http.HandleFunc("/api/produk/bulk-min-stock", productHandler.HandleBulkMinStock)
+	http.HandleFunc("/api/produk/opening-stock", productHandler.HandleOpeningStock)
+	http.HandleFunc("/api/produk/reconcile-stock", handlers.RequireAdmin(config.AdminToken, productHandler.HandleReconcileStock))
+	http.HandleFunc("/api/produk/expiring", productHandler.GetExpiring)
+	http.HandleFunc("/api/produk/favorites", productHandler.GetFavorites)
+	http.HandleFunc("/api/produk/grouped", productHandler.GetGrouped)
+	http.HandleFunc("/api/produk/out-of-stock", productHandler.GetOutOfStock)
+	http.HandleFunc("/api/produk/stock-status", productHandler.GetStockStatus)
+	http.HandleFunc("/api/produk/low-stock", productHandler.GetLowStock)
+	http.HandleFunc("/api/produk/low-stock/stream", stockAlertHandler.HandleLowStockStream)
 
 	http.HandleFunc("/api/kategori", categoryHandler.HandleCategories)
 	http.HandleFunc("/api/kategori/", categoryHandler.HandleCategoryByID)
+	http.HandleFunc("/api/kategori/by-name", categoryHandler.GetByName)
+	http.HandleFunc("/api/kategori/batch", categoryHandler.GetByIDs)
 
 	http.HandleFunc("/api/checkout", transactionHandler.HandleCheckout)
+	http.HandleFunc("/api/checkout/batch", transactionHandler.BatchCheckout)
+	http.HandleFunc("/api/transaksi", transactionHandler.HandleTransactions)
+	http.HandleFunc("/api/transaksi/", transactionHandler.HandleTransactionByID)
+	http.HandleFunc("/api/transaksi/cursor", transactionHandler.ListByCursor)
+	http.HandleFunc("/api/transaksi/export-details", transactionHandler.ExportDetails)
+	http.HandleFunc("/api/transaksi/void-batch", handlers.RequireAdmin(config.AdminToken, transactionHandler.VoidBatch))
+
+	http.HandleFunc("/api/customers/", customerHandler.HandleCustomerByPhone)
+
+	http.HandleFunc("/api/import", importHandler.HandleImport)
+
+	http.HandleFunc("/api/promo/", promoHandler.HandlePromo)
 
 	http.HandleFunc("/api/report", reportHandler.HandleReport)
 	http.HandleFunc("/api/report/", reportHandler.HandleReport)
+	http.HandleFunc("/api/report/ticket-distribution", reportHandler.HandleTicketDistribution)
+	http.HandleFunc("/api/report/throughput", reportHandler.HandleThroughput)
+	http.HandleFunc("/api/report/basket-size", reportHandler.HandleBasketSize)
+	http.HandleFunc("/api/report/closing", reportHandler.HandleDailyClosing)
+	http.HandleFunc("/api/report/reorder-by-kategori", reportHandler.HandleReorderByCategory)
+	http.HandleFunc("/api/report/transaction-interval", reportHandler.HandleTransactionInterval)
+	http.HandleFunc("/api/report/daily", reportHandler.HandleDailyBreakdown)
+	http.HandleFunc("/api/report/hourly-pattern", reportHandler.HandleHourlyPattern)
+	http.HandleFunc("/api/report/dates", reportHandler.HandleReportForDates)
+	http.HandleFunc("/api/report/pareto", reportHandler.HandlePareto)
+	http.HandleFunc("/api/report/anomalies", reportHandler.HandleAnomalies)
+	http.HandleFunc("/api/report/category-attach-rate", reportHandler.HandleCategoryAttachRate)
+	http.HandleFunc("/api/report/snapshot", reportHandler.HandleDailySnapshot)
+	http.HandleFunc("/api/report/kpi", reportHandler.HandleKPI)
+	http.HandleFunc("/api/report/customer-mix", reportHandler.HandleCustomerMix)
+	http.HandleFunc("/api/report/stock-cover", reportHandler.HandleStockCover)
+	http.HandleFunc("/api/report/profit", reportHandler.HandleProfit)
+	http.HandleFunc("/api/report/kategori", reportHandler.HandleRevenueByCategory)
+	http.HandleFunc("/api/report/discounts", reportHandler.HandleDiscounts)
+	http.HandleFunc("/api/report/tax", reportHandler.HandleTax)
+
+	http.HandleFunc("/api/features", featureHandler.HandleFeatures)
+
+	http.HandleFunc("/api/admin/maintenance", handlers.RequireAdmin(config.AdminToken, adminHandler.HandleMaintenance))
+	http.HandleFunc("/api/admin/schema-check", handlers.RequireAdmin(config.AdminToken, adminHandler.HandleSchemaCheck))
 
 	//  localhost:8080/health
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
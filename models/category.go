@@ -4,4 +4,10 @@ type Category struct {
 	ID          int    `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// MinStock adalah batas stok minimum default untuk produk di kategori ini yang tidak punya min_stock sendiri
+	MinStock *int `json:"min_stock"`
+}
+
+type CategoryBatchRequest struct {
+	IDs []int `json:"ids"`
 }
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Customer adalah pelanggan program loyalty, diidentifikasi dengan nomor HP
+type Customer struct {
+	ID        int       `json:"id"`
+	Phone     string    `json:"phone"`
+	Name      string    `json:"name"`
+	Points    int       `json:"points"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoyaltyConfig mengatur perhitungan poin loyalty
+// SpendPerPoint: setiap kelipatan belanja ini mendapat 1 poin
+// PointValue: nilai rupiah 1 poin saat ditukar sebagai diskon
+type LoyaltyConfig struct {
+	SpendPerPoint int
+	PointValue    int
+}
@@ -0,0 +1,31 @@
+package models
+
+// ImportRequest adalah dokumen katalog lengkap untuk onboarding sekaligus
+// Produk merujuk kategori lewat nama, bisa kategori baru di dokumen ini atau yang sudah ada
+type ImportRequest struct {
+	Categories []ImportCategory `json:"categories"`
+	Products   []ImportProduct  `json:"products"`
+}
+
+// ImportCategory adalah satu kategori di dokumen import
+// Row adalah index aslinya di dokumen, dipakai untuk pesan error walaupun baris lain sudah dibuang
+type ImportCategory struct {
+	Category
+	Row int `json:"-"`
+}
+
+type ImportProduct struct {
+	Name         string `json:"name"`
+	Price        int    `json:"price"`
+	Stock        int    `json:"stock"`
+	CategoryName string `json:"category_name"`
+	// Row adalah index asli produk di dokumen import, diisi oleh service
+	Row int `json:"-"`
+}
+
+// ImportResult berisi jumlah data yang dibuat dan error per baris yang dilewati
+type ImportResult struct {
+	CategoriesCreated int      `json:"categories_created"`
+	ProductsCreated   int      `json:"products_created"`
+	Errors            []string `json:"errors"`
+}
@@ -1,10 +1,131 @@
 package models
 
+import "time"
+
+// Nilai StockStatus yang dihitung di service layer
+const (
+	StockStatusOutOfStock = "out_of_stock"
+	StockStatusLow        = "low"
+	StockStatusAvailable  = "available"
+)
+
 type Product struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	Price        int    `json:"price"`
-	Stock        int    `json:"stock"`
-	CategoryID   *int   `json:"category_id"`
-	CategoryName string `json:"category_name,omitempty"`
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	SKU       string `json:"sku"`
+	Price     int    `json:"price"`
+	CostPrice int    `json:"cost_price"`
+	// Margin dihitung dari price - cost_price, tidak disimpan di database
+	Margin   int  `json:"margin"`
+	Stock    int  `json:"stock"`
+	MinStock *int `json:"min_stock"`
+	// EffectiveMinStock adalah min_stock produk, atau min_stock kategori jika produk tidak mengisinya
+	// Hanya dibaca dari database dan dipakai untuk menentukan stock_status
+	EffectiveMinStock *int       `json:"effective_min_stock"`
+	ExpiryDate        *time.Time `json:"expiry_date"`
+	IsFavorite        bool       `json:"is_favorite"`
+	IsTaxExempt       bool       `json:"is_tax_exempt"`
+	CategoryID        *int       `json:"category_id"`
+	CategoryName      string     `json:"category_name,omitempty"`
+	StockStatus       string     `json:"stock_status,omitempty"`
+	// CreatedAt dan UpdatedAt di-encode sebagai RFC3339; UpdatedAt berubah saat data produk diubah, bukan saat stok terjual
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductFilter berisi filter dan urutan untuk daftar produk, field kosong berarti tidak difilter
+type ProductFilter struct {
+	Name string
+	// CategoryID nil berarti semua kategori
+	CategoryID *int
+	// MinPrice dan MaxPrice nil berarti tanpa batas harga
+	MinPrice *int
+	MaxPrice *int
+	// Sort salah satu key di repositories.ProductSortOrders, kosong berarti id_asc
+	Sort string
+}
+
+// ProductPage adalah satu halaman daftar produk beserta total seluruh produk yang cocok dengan filter
+type ProductPage struct {
+	Data  []Product `json:"data"`
+	Total int       `json:"total"`
+	Page  int       `json:"page"`
+	Size  int       `json:"size"`
+}
+
+// ProductPatch adalah satu item pada batch partial update produk
+// Fields hanya berisi kolom yang ingin diubah, contoh {"price": 5000, "category_id": 2}
+type ProductPatch struct {
+	ID     int                    `json:"id"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// ProductVelocity berisi kecepatan penjualan produk dalam N hari terakhir
+// DaysUntilStockout nil jika produk tidak terjual sama sekali (velocity 0)
+type ProductVelocity struct {
+	ProductID         int      `json:"product_id"`
+	Days              int      `json:"days"`
+	TotalSold         int      `json:"total_sold"`
+	AvgPerDay         float64  `json:"avg_per_day"`
+	Stock             int      `json:"stock"`
+	DaysUntilStockout *float64 `json:"days_until_stockout"`
+}
+
+// ProductGroup adalah sekumpulan produk dalam satu kategori untuk tampilan menu
+type ProductGroup struct {
+	Category Category  `json:"category"`
+	Products []Product `json:"products"`
+}
+
+// ProductStockStatus adalah ringkasan stok produk untuk tabel manajemen inventori
+type ProductStockStatus struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Stock int    `json:"stock"`
+	// MinStock adalah min_stock efektif: milik produk, atau milik kategori jika produk tidak mengisinya
+	MinStock *int   `json:"min_stock"`
+	Status   string `json:"status"`
+}
+
+// StockStatusRequest adalah body untuk POST /api/produk/stock-status
+// IDs kosong berarti semua produk
+type StockStatusRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// MinStockUpdate adalah satu item pada bulk update min_stock per produk
+type MinStockUpdate struct {
+	ProductID int  `json:"product_id"`
+	MinStock  *int `json:"min_stock"`
+}
+
+// CategoryMinStockUpdate mengubah min_stock semua produk dalam satu kategori
+type CategoryMinStockUpdate struct {
+	CategoryID *int `json:"category_id"`
+	MinStock   *int `json:"min_stock"`
+}
+
+// BulkMinStockResult adalah jumlah produk yang min_stock-nya diubah
+type BulkMinStockResult struct {
+	Updated int `json:"updated"`
+}
+
+// TrendPoint adalah penjualan dalam satu periode, PeriodStart adalah tanggal awal periode (YYYY-MM-DD)
+type TrendPoint struct {
+	PeriodStart string `json:"period_start"`
+	Quantity    int    `json:"quantity"`
+	Revenue     int    `json:"revenue"`
+}
+
+// ProductTrend adalah tren penjualan satu produk selama N periode terakhir (terlama di awal)
+type ProductTrend struct {
+	ProductID int          `json:"product_id"`
+	Period    string       `json:"period"`
+	Points    []TrendPoint `json:"points"`
+}
+
+// BulkCreateResult adalah hasil bulk create produk, IDs sesuai urutan produk di request
+type BulkCreateResult struct {
+	Created int   `json:"created"`
+	IDs     []int `json:"ids"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ProductAudit adalah satu entri riwayat perubahan produk
+// Payload berisi data perubahan sesuai jenis Action (create, update, patch, delete, stock_movement, favorite)
+type ProductAudit struct {
+	ID        int             `json:"id"`
+	ProductID int             `json:"product_id"`
+	Action    string          `json:"action"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// PromoSnapshotRequest adalah body untuk POST /api/promo/snapshot
+type PromoSnapshotRequest struct {
+	Name string `json:"name"`
+}
+
+// PromoSnapshotResult adalah ringkasan snapshot harga yang tersimpan
+type PromoSnapshotResult struct {
+	Name          string `json:"name"`
+	ProductsSaved int    `json:"products_saved"`
+}
+
+// PromoApplyResult adalah hasil penerapan promo set ke harga produk
+// SkippedProductIDs berisi produk di snapshot yang sudah dihapus sehingga tidak bisa diterapkan
+type PromoApplyResult struct {
+	Name              string `json:"name"`
+	Applied           int    `json:"applied"`
+	SkippedProductIDs []int  `json:"skipped_product_ids"`
+}
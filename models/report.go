@@ -1,12 +1,293 @@
 package models
 
+import "time"
+
 type ProdukTerlaris struct {
-	Nama      string `json:"nama"`
+	Nama       string `json:"nama"`
 	QtyTerjual int    `json:"qty_terjual"`
 }
 
 type ReportResponse struct {
-	TotalRevenue    int            `json:"total_revenue"`
-	TotalTransaksi  int            `json:"total_transaksi"`
-	ProdukTerlaris  ProdukTerlaris `json:"produk_terlaris"`
+	TotalRevenue   int `json:"total_revenue"`
+	TotalTransaksi int `json:"total_transaksi"`
+	// ProdukTerlaris adalah N produk terlaris berdasarkan qty, terurut dari yang terbanyak
+	ProdukTerlaris []ProdukTerlaris `json:"produk_terlaris"`
+}
+
+// DailyReport berisi revenue dan jumlah transaksi untuk satu tanggal
+type DailyReport struct {
+	Tanggal        string `json:"tanggal"`
+	TotalRevenue   int    `json:"total_revenue"`
+	TotalTransaksi int    `json:"total_transaksi"`
+}
+
+// DetailedReportResponse adalah report range lengkap untuk halaman report dalam satu request
+type DetailedReportResponse struct {
+	ReportResponse
+	DailyRevenue []DailyReport    `json:"daily_revenue"`
+	TopProducts  []ProdukTerlaris `json:"top_products"`
+}
+
+// TicketBucket adalah satu kelompok transaksi berdasarkan rentang total belanja
+// MaxAmount nil berarti bucket terakhir (tanpa batas atas)
+type TicketBucket struct {
+	Label     string `json:"label"`
+	MinAmount int    `json:"min_amount"`
+	MaxAmount *int   `json:"max_amount"`
+	Count     int    `json:"count"`
+	Revenue   int    `json:"revenue"`
+}
+
+// ThroughputReport berisi angka puncak transaksi per jam untuk perencanaan kapasitas kasir
+type ThroughputReport struct {
+	TotalTransaksi      int        `json:"total_transaksi"`
+	JamAktif            int        `json:"jam_aktif"`
+	RataRataPerJamAktif float64    `json:"rata_rata_per_jam_aktif"`
+	PeakHour            *time.Time `json:"peak_hour"`
+	PeakHourTransaksi   int        `json:"peak_hour_transaksi"`
+	BusiestHourOfDay    *int       `json:"busiest_hour_of_day"`
+	BusiestHourAvg      float64    `json:"busiest_hour_avg_transaksi"`
+}
+
+// BasketSize berisi rata-rata ukuran keranjang per hari
+type BasketSize struct {
+	Tanggal          string  `json:"tanggal"`
+	TotalTransaksi   int     `json:"total_transaksi"`
+	AvgDistinctItems float64 `json:"avg_distinct_items"`
+	AvgQuantity      float64 `json:"avg_quantity"`
+}
+
+// DailyClosing adalah ringkasan tutup kasir untuk satu tanggal
+type DailyClosing struct {
+	Tanggal        string           `json:"tanggal"`
+	TotalRevenue   int              `json:"total_revenue"`
+	TotalTransaksi int              `json:"total_transaksi"`
+	ItemTerjual    int              `json:"item_terjual"`
+	TopProducts    []ProdukTerlaris `json:"top_products"`
+	// PaymentMethods adalah rincian revenue per metode pembayaran untuk mencocokkan kas, kartu, dan QRIS
+	PaymentMethods []PaymentMethodTotal `json:"payment_methods"`
+}
+
+// PaymentMethodTotal adalah revenue dan jumlah transaksi untuk satu metode pembayaran
+type PaymentMethodTotal struct {
+	PaymentMethod  string `json:"payment_method"`
+	TotalRevenue   int    `json:"total_revenue"`
+	TotalTransaksi int    `json:"total_transaksi"`
+}
+
+// DailySnapshot adalah ringkasan tutup kasir yang sudah disimpan di tabel daily_snapshots
+// Tetap bisa dibaca walaupun data transaksi mentah sudah dihapus
+type DailySnapshot struct {
+	DailyClosing
+	SnapshotAt time.Time `json:"snapshot_at"`
+}
+
+// ReorderItem adalah produk yang stoknya sudah mencapai reorder point
+// ReorderPoint adalah min_stock produk, atau min_stock kategori jika produk tidak punya
+type ReorderItem struct {
+	ProductID    int    `json:"product_id"`
+	Nama         string `json:"nama"`
+	Stock        int    `json:"stock"`
+	ReorderPoint int    `json:"reorder_point"`
+}
+
+// ReorderGroup mengelompokkan produk yang perlu di-restock per kategori
+type ReorderGroup struct {
+	CategoryID   int           `json:"category_id"`
+	CategoryName string        `json:"category_name"`
+	Products     []ReorderItem `json:"products"`
+}
+
+// TransactionInterval berisi jarak waktu antar transaksi berurutan (dalam detik)
+// Nilai interval nil jika dalam range kurang dari dua transaksi pada hari yang sama
+type TransactionInterval struct {
+	TotalTransaksi     int      `json:"total_transaksi"`
+	AvgIntervalSeconds *float64 `json:"avg_interval_seconds"`
+	MinIntervalSeconds *float64 `json:"min_interval_seconds"`
+	MaxIntervalSeconds *float64 `json:"max_interval_seconds"`
+}
+
+// HourlyPattern adalah rata-rata penjualan pada satu jam (0-23) per hari dalam sebuah range
+type HourlyPattern struct {
+	Jam            int     `json:"jam"`
+	AvgRevenue     float64 `json:"avg_revenue"`
+	AvgTransaksi   float64 `json:"avg_transaksi"`
+	TotalRevenue   int     `json:"total_revenue"`
+	TotalTransaksi int     `json:"total_transaksi"`
+}
+
+type ReportDatesRequest struct {
+	Dates []string `json:"dates"`
+}
+
+// ProductRevenue adalah total revenue satu produk dalam sebuah range
+type ProductRevenue struct {
+	ProductID int    `json:"product_id"`
+	Nama      string `json:"nama"`
+	Revenue   int    `json:"revenue"`
+}
+
+// ParetoPoint adalah satu titik kurva kumulatif revenue, diurutkan dari produk dengan revenue terbesar
+type ParetoPoint struct {
+	ProductRevenue
+	CumulativeRevenueShare float64 `json:"cumulative_revenue_share"`
+	CumulativeProductShare float64 `json:"cumulative_product_share"`
+}
+
+// ParetoReport menunjukkan seberapa besar revenue bergantung pada 20% produk teratas
+type ParetoReport struct {
+	TotalRevenue    int           `json:"total_revenue"`
+	TotalProduk     int           `json:"total_produk"`
+	TopProductCount int           `json:"top_product_count"`
+	TopRevenueShare float64       `json:"top_revenue_share"`
+	Curve           []ParetoPoint `json:"curve"`
+}
+
+// AnomalyTransaction adalah transaksi yang totalnya melebihi batas wajar
+type AnomalyTransaction struct {
+	ID          int       `json:"id"`
+	TotalAmount int       `json:"total_amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AnomalyReport berisi transaksi dengan total > multiplier x rata-rata transaksi dalam range
+type AnomalyReport struct {
+	AverageTicket float64              `json:"average_ticket"`
+	Multiplier    float64              `json:"multiplier"`
+	Threshold     float64              `json:"threshold"`
+	Transactions  []AnomalyTransaction `json:"transactions"`
+}
+
+// CategoryAttachRate berisi seberapa sering sebuah kategori muncul di keranjang belanja
+// AttachRate = transaksi yang berisi kategori / total transaksi dalam range
+// AvgQuantity = rata-rata qty kategori tersebut per transaksi yang berisi kategori itu
+type CategoryAttachRate struct {
+	CategoryID     int     `json:"category_id"`
+	CategoryName   string  `json:"category_name"`
+	TotalTransaksi int     `json:"total_transaksi"`
+	AttachRate     float64 `json:"attach_rate"`
+	AvgQuantity    float64 `json:"avg_quantity"`
+}
+
+// InventorySummary berisi nilai inventori (harga jual x stok) dan jumlah produk per status stok saat ini
+type InventorySummary struct {
+	InventoryValue  int `json:"inventory_value"`
+	LowStockCount   int `json:"low_stock_count"`
+	OutOfStockCount int `json:"out_of_stock_count"`
+}
+
+// KPIReport adalah ringkasan penjualan dan inventori untuk dashboard dalam satu response
+// Inventori selalu kondisi saat ini, bukan kondisi di akhir range
+type KPIReport struct {
+	StartDate      string         `json:"start_date"`
+	EndDate        string         `json:"end_date"`
+	TotalRevenue   int            `json:"total_revenue"`
+	TotalTransaksi int            `json:"total_transaksi"`
+	AverageTicket  float64        `json:"average_ticket"`
+	ProdukTerlaris ProdukTerlaris `json:"produk_terlaris"`
+	// Profit dan ProfitMarginPercent sama dengan /api/report/profit untuk range yang sama
+	Profit              int     `json:"profit"`
+	ProfitMarginPercent float64 `json:"profit_margin_percent"`
+	InventorySummary
+}
+
+// CustomerMixBucket berisi jumlah transaksi dan revenue untuk satu kelompok pelanggan
+// Segment: new, returning, atau anonymous (transaksi tanpa pelanggan)
+type CustomerMixBucket struct {
+	Segment        string `json:"segment"`
+	TotalCustomers int    `json:"total_customers"`
+	TotalTransaksi int    `json:"total_transaksi"`
+	TotalRevenue   int    `json:"total_revenue"`
+}
+
+// StockCover berisi perkiraan berapa hari stok produk akan bertahan berdasarkan rata-rata penjualan harian
+// DaysOfCover nil jika produk tidak terjual sama sekali dalam periode (velocity 0)
+type StockCover struct {
+	ProductID   int      `json:"product_id"`
+	Nama        string   `json:"nama"`
+	Stock       int      `json:"stock"`
+	TotalSold   int      `json:"total_sold"`
+	AvgPerDay   float64  `json:"avg_per_day"`
+	DaysOfCover *float64 `json:"days_of_cover"`
+	// BelowMinDays true jika DaysOfCover kurang dari batas min_days
+	BelowMinDays bool `json:"below_min_days"`
+}
+
+// CategoryTrend adalah tren penjualan satu kategori selama N periode terakhir (terlama di awal)
+type CategoryTrend struct {
+	CategoryID int          `json:"category_id"`
+	Period     string       `json:"period"`
+	Points     []TrendPoint `json:"points"`
+}
+
+// ProfitReport adalah laba kotor dalam range berdasarkan harga modal (cost_price) produk saat ini
+// Item dengan harga modal 0 (belum diisi) tidak ikut dihitung di TotalCost, Profit, dan ProfitMarginPercent
+// agar tidak terlihat untung 100%; revenue-nya dilaporkan terpisah di RevenueWithoutCost
+type ProfitReport struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// TotalRevenue adalah penjualan bersih tanpa pajak: subtotal item dikurangi diskon transaksi dan diskon loyalty
+	// Selisihnya dengan total_revenue /api/report adalah pajak, karena total_revenue report sudah termasuk pajak
+	TotalRevenue int `json:"total_revenue"`
+	TotalCost    int `json:"total_cost"`
+	Profit       int `json:"profit"`
+	// ProfitMarginPercent = Profit / revenue item yang punya harga modal * 100
+	ProfitMarginPercent float64 `json:"profit_margin_percent"`
+	RevenueWithoutCost  int     `json:"revenue_without_cost"`
+	ProductsWithoutCost int     `json:"products_without_cost"`
+}
+
+// CategoryRevenue adalah total revenue satu kategori dalam sebuah range
+// Revenue adalah subtotal item setelah diskon transaksi dan diskon loyalty dibagi proporsional, tanpa pajak
+// Produk tanpa kategori (atau yang sudah dihapus) masuk ke Uncategorized (id 0)
+type CategoryRevenue struct {
+	CategoryID     int    `json:"category_id"`
+	CategoryName   string `json:"category_name"`
+	Revenue        int    `json:"revenue"`
+	TotalTransaksi int    `json:"total_transaksi"`
+}
+
+// CategoryRevenueReport adalah revenue per kategori yang bisa direkonsiliasi dengan total_revenue /api/report:
+// jumlah revenue kategori + Tax + RoundingAdjustment = TotalRevenue
+type CategoryRevenueReport struct {
+	StartDate  string            `json:"start_date"`
+	EndDate    string            `json:"end_date"`
+	Categories []CategoryRevenue `json:"categories"`
+	// Tax adalah pajak transaksi dalam range, tidak dibagi ke kategori
+	Tax int `json:"tax"`
+	// RoundingAdjustment adalah selisih pembulatan dari pembagian diskon transaksi ke item
+	RoundingAdjustment int `json:"rounding_adjustment"`
+	// TotalRevenue sama dengan total_revenue /api/report untuk range yang sama (termasuk pajak)
+	TotalRevenue int `json:"total_revenue"`
+}
+
+// DiscountReport adalah ringkasan diskon yang diberikan dalam sebuah range (transaksi void tidak dihitung)
+// Belum bisa dirinci per kasir karena transaksi belum menyimpan kasir yang melayani
+type DiscountReport struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// ItemDiscount adalah jumlah diskon per item, TransactionDiscount jumlah discount_amount transaksi
+	ItemDiscount        int `json:"item_discount"`
+	TransactionDiscount int `json:"transaction_discount"`
+	// LoyaltyDiscount adalah potongan dari penukaran poin, bukan diskon yang diberikan kasir
+	LoyaltyDiscount int `json:"loyalty_discount"`
+	// TotalDiscount = ItemDiscount + TransactionDiscount
+	TotalDiscount          int `json:"total_discount"`
+	DiscountedTransactions int `json:"discounted_transactions"`
+	TotalTransaksi         int `json:"total_transaksi"`
+	// MaxDiscountPercent adalah batas diskon yang berlaku saat ini (MAX_DISCOUNT_PERCENT)
+	MaxDiscountPercent float64 `json:"max_discount_percent"`
+}
+
+// TaxReport adalah pajak yang terkumpul dalam sebuah range untuk pelaporan pajak (transaksi void tidak dihitung)
+// TaxableSubtotal dan ExemptSubtotal adalah subtotal item kena pajak dan bebas pajak sebelum diskon transaksi
+// Semua nilai 0 jika fitur pajak (FEATURE_TAX) dimatikan
+type TaxReport struct {
+	StartDate       string `json:"start_date"`
+	EndDate         string `json:"end_date"`
+	TaxEnabled      bool   `json:"tax_enabled"`
+	TaxCollected    int    `json:"tax_collected"`
+	TaxableSubtotal int    `json:"taxable_subtotal"`
+	ExemptSubtotal  int    `json:"exempt_subtotal"`
+	TotalTransaksi  int    `json:"total_transaksi"`
 }
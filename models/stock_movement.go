@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// StockMovement mencatat setiap perubahan stok di luar penjualan (write-off, koreksi, dll)
+// Delta negatif berarti stok berkurang
+type StockMovement struct {
+	ID        int       `json:"id"`
+	ProductID int       `json:"product_id"`
+	Delta     int       `json:"delta"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WriteOffRequest struct {
+	Reason string `json:"reason"`
+}
+
+// StockAdjustRequest adalah body koreksi stok manual, contoh {"delta": -3, "reason": "rusak"}
+type StockAdjustRequest struct {
+	Delta  int    `json:"delta"`
+	Reason string `json:"reason"`
+}
+
+// StockAdjustment adalah movement hasil koreksi stok beserta stok produk setelah koreksi
+type StockAdjustment struct {
+	StockMovement
+	Stock int `json:"stock"`
+}
+
+// OpeningStockItem adalah saldo stok awal satu produk saat migrasi dari sistem lain
+type OpeningStockItem struct {
+	ProductID int  `json:"product_id"`
+	Stock     *int `json:"stock"`
+}
+
+// StockDiscrepancy adalah produk yang stok tercatatnya berbeda dari hasil hitung ulang riwayat
+// ExpectedStock = total stock movement - total qty terjual, keduanya dihitung sejak movement opening_balance
+type StockDiscrepancy struct {
+	ProductID     int    `json:"product_id"`
+	Name          string `json:"name"`
+	RecordedStock int    `json:"recorded_stock"`
+	ExpectedStock int    `json:"expected_stock"`
+	Difference    int    `json:"difference"`
+}
+
+// StockReconcileResult adalah hasil rekonsiliasi stok, Fixed true jika stok sudah dikoreksi
+type StockReconcileResult struct {
+	Checked       int                `json:"checked"`
+	Fixed         bool               `json:"fixed"`
+	Discrepancies []StockDiscrepancy `json:"discrepancies"`
+}
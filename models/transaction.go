@@ -1,9 +1,30 @@
 package models
 
+import "time"
+
 type Transaction struct {
-	ID          int                  `json:"id"`
-	TotalAmount int                  `json:"total_amount"`
-	Details     []TransactionDetails `json:"details"`
+	ID int `json:"id"`
+	// Subtotal adalah jumlah subtotal item, TotalAmount = Subtotal - Discount - LoyaltyDiscount + Tax
+	Subtotal int `json:"subtotal"`
+	// TaxableSubtotal dan ExemptSubtotal membagi Subtotal menjadi item yang kena pajak dan yang bebas pajak
+	TaxableSubtotal int    `json:"taxable_subtotal"`
+	ExemptSubtotal  int    `json:"exempt_subtotal"`
+	Discount        int    `json:"discount"`
+	Tax             int    `json:"tax"`
+	TotalAmount     int    `json:"total_amount"`
+	CustomerPhone   string `json:"customer_phone,omitempty"`
+	PointsEarned    int    `json:"points_earned"`
+	PointsRedeemed  int    `json:"points_redeemed"`
+	LoyaltyDiscount int    `json:"loyalty_discount"`
+	PaymentMethod   string `json:"payment_method"`
+	AmountPaid      int    `json:"amount_paid"`
+	// Change adalah kembalian (AmountPaid - TotalAmount), selalu 0 untuk pembayaran non-tunai
+	Change         int                  `json:"change"`
+	CreatedAt      time.Time            `json:"created_at"`
+	VoidedAt       *time.Time           `json:"voided_at,omitempty"`
+	Status         string               `json:"status"`
+	IdempotencyKey string               `json:"idempotency_key,omitempty"`
+	Details        []TransactionDetails `json:"details"`
 }
 type TransactionDetails struct {
 	ID            int    `json:"id"`
@@ -11,14 +32,118 @@ type TransactionDetails struct {
 	ProductID     int    `json:"product_id"`
 	ProductName   string `json:"product_name"`
 	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	// Discount adalah potongan untuk item ini, Subtotal = harga * qty - Discount
+	Discount int `json:"discount"`
+	Subtotal int `json:"subtotal"`
 }
 
 type CheckoutRequest struct {
 	Items []CheckoutItem `json:"items"`
+	// CustomerPhone opsional, jika diisi transaksi mendapat poin loyalty
+	CustomerPhone string `json:"customer_phone"`
+	CustomerName  string `json:"customer_name"`
+	// RedeemPoints adalah jumlah poin yang ditukar sebagai diskon
+	RedeemPoints int `json:"redeem_points"`
+	// IdempotencyKey opsional, checkout dengan key yang sama tidak akan membuat transaksi baru
+	IdempotencyKey string `json:"idempotency_key"`
+	// DiscountAmount adalah potongan nominal untuk seluruh transaksi, tidak boleh melebihi subtotal
+	DiscountAmount int `json:"discount_amount"`
+	// TaxPercent adalah persentase pajak (misal 11), hanya dikenakan pada produk yang tidak is_tax_exempt
+	TaxPercent float64 `json:"tax_percent"`
+	// PaymentMethod salah satu cash, card, qris; kosong berarti cash dibayar pas
+	PaymentMethod string `json:"payment_method"`
+	// AmountPaid adalah uang yang dibayarkan, wajib >= total untuk cash; untuk non-tunai selalu sama dengan total
+	AmountPaid int `json:"amount_paid"`
 }
 
+// Metode pembayaran yang diterima checkout
+const (
+	PaymentCash = "cash"
+	PaymentCard = "card"
+	PaymentQRIS = "qris"
+)
+
 type CheckoutItem struct {
 	ProductID int `json:"product_id"`
 	Quantity  int `json:"quantity"`
+	// Discount opsional, potongan nominal untuk item ini (misal kemasan rusak)
+	Discount int `json:"discount"`
+}
+
+// ProductSale adalah satu baris penjualan sebuah produk di dalam transaksi
+type ProductSale struct {
+	TransactionID int       `json:"transaction_id"`
+	Quantity      int       `json:"quantity"`
+	Subtotal      int       `json:"subtotal"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TransactionDetailExport adalah satu baris item transaksi untuk export akuntansi
+type TransactionDetailExport struct {
+	TransactionID int
+	CreatedAt     time.Time
+	PaymentMethod string
+	ProductName   string
+	Quantity      int
+	UnitPrice     int
+	Subtotal      int
+}
+
+// VoidBatchRequest adalah body untuk POST /api/transaksi/void-batch
+// Confirm wajib true agar void massal tidak terjadi karena request yang tidak disengaja
+type VoidBatchRequest struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Confirm   bool   `json:"confirm"`
+}
+
+// Nilai Transaction.Status
+const (
+	TransactionStatusCompleted = "completed"
+	TransactionStatusCancelled = "cancelled"
+	TransactionStatusVoided    = "voided"
+)
+
+// VoidBatchResult adalah ringkasan hasil void massal
+type VoidBatchResult struct {
+	VoidedCount   int `json:"voided_count"`
+	StockRestored int `json:"stock_restored"`
+}
+
+// TransactionFilter berisi filter riwayat transaksi, StartDate dan EndDate (YYYY-MM-DD) kosong berarti semua tanggal
+type TransactionFilter struct {
+	StartDate string
+	EndDate   string
+}
+
+// TransactionPage adalah satu halaman riwayat transaksi beserta total seluruh transaksi
+type TransactionPage struct {
+	Data  []Transaction `json:"data"`
+	Total int           `json:"total"`
+	Page  int           `json:"page"`
+	Size  int           `json:"size"`
+}
+
+// TransactionCursorPage adalah satu halaman transaksi dengan cursor pagination
+// NextCursor nil berarti tidak ada halaman berikutnya
+type TransactionCursorPage struct {
+	Data       []Transaction `json:"data"`
+	NextCursor *int          `json:"next_cursor"`
+}
+
+// Status hasil satu entri batch checkout
+const (
+	BatchCheckoutCreated   = "created"
+	BatchCheckoutDuplicate = "duplicate"
+	BatchCheckoutFailed    = "failed"
+)
+
+// BatchCheckoutResult adalah hasil satu entri pada batch checkout (sinkronisasi offline)
+// TransactionID terisi untuk status created dan duplicate, Error terisi untuk status failed
+type BatchCheckoutResult struct {
+	Index          int    `json:"index"`
+	IdempotencyKey string `json:"idempotency_key"`
+	Status         string `json:"status"`
+	TransactionID  *int   `json:"transaction_id,omitempty"`
+	Error          string `json:"error,omitempty"`
 }
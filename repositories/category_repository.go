@@ -2,8 +2,9 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
 	"kasir-api/models"
+
+	"github.com/lib/pq"
 )
 
 // CategoryRepository adalah struct yang mengelola operasi database untuk tabel categories
@@ -29,7 +30,7 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 // Mengembalikan slice kategori dan error jika ada
 func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
 	// Query SQL untuk mengambil semua kategori dari tabel categories
-	query := "SELECT id, name, description FROM categories"
+	query := "SELECT id, name, description, min_stock FROM categories"
 
 	// Eksekusi query ke database dan simpan hasilnya dalam rows
 	// Kenapa menggunakan Query()? Karena kita expect multiple rows (banyak kategori)
@@ -55,9 +56,9 @@ func (repo *CategoryRepository) GetAll() ([]models.Category, error) {
 		// Kenapa var c models.Category? Untuk menyimpan hasil scan setiap iterasi
 		var c models.Category
 		// Scan data dari baris saat ini ke dalam struct Category
-		// Kenapa pakai &c.ID, &c.Name, &c.Description? Scan butuh pointer untuk mengisi nilai
+		// Kenapa pakai &c.ID, &c.Name, &c.Description, &c.MinStock? Scan butuh pointer untuk mengisi nilai
 		// Kenapa urutan harus sama? Harus sesuai urutan kolom di SELECT query
-		err := rows.Scan(&c.ID, &c.Name, &c.Description)
+		err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.MinStock)
 		// Cek apakah ada error saat scanning data
 		if err != nil {
 			// Kembalikan nil dan error jika scanning gagal
@@ -80,20 +81,20 @@ func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 	// Query SQL untuk mengambil satu kategori berdasarkan ID dengan placeholder $1
 	// Kenapa $1? Placeholder untuk prepared statement (mencegah SQL injection)
 	// Kenapa WHERE id = $1? Filter untuk mengambil kategori dengan ID tertentu
-	query := "SELECT id, name, description FROM categories WHERE id = $1"
+	query := "SELECT id, name, description, min_stock FROM categories WHERE id = $1"
 	// Deklarasi variabel untuk menyimpan hasil kategori yang akan di-scan
 	var c models.Category
 	// Eksekusi query dengan QueryRow (mengembalikan max 1 baris) dan langsung scan hasilnya
 	// Kenapa QueryRow bukan Query? Karena kita expect maksimal 1 row berdasarkan ID (primary key)
 	// Kenapa langsung .Scan()? QueryRow mengembalikan *Row yang bisa langsung di-scan
 	// Kenapa parameter id? Nilai yang akan menggantikan placeholder $1
-	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description)
+	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description, &c.MinStock)
 	// Cek apakah data tidak ditemukan (ErrNoRows)
 	// Kenapa cek sql.ErrNoRows khusus? Untuk membedakan "data tidak ada" vs "error database"
 	if err == sql.ErrNoRows {
 		// Kembalikan nil dan error custom jika kategori tidak ada di database
 		// Kenapa return nil? Karena tidak ada data yang bisa dikembalikan
-		return nil, errors.New("category not found")
+		return nil, &NotFoundError{Resource: "category", ID: id}
 	}
 	// Cek error lainnya seperti error koneksi atau scanning
 	if err != nil {
@@ -105,6 +106,50 @@ func (repo *CategoryRepository) GetByID(id int) (*models.Category, error) {
 	return &c, nil
 }
 
+// GetByIDs mengambil beberapa kategori sekaligus berdasarkan daftar ID
+// Kenapa perlu? Form produk sering butuh beberapa kategori, satu query lebih hemat daripada banyak GetByID
+// ID yang tidak ada di database tidak menghasilkan error, cukup tidak muncul di hasil
+func (repo *CategoryRepository) GetByIDs(ids []int) ([]models.Category, error) {
+	// Kenapa ANY($1)? Satu placeholder untuk seluruh array, tidak perlu menyusun IN ($1, $2, ...) secara manual
+	query := "SELECT id, name, description, min_stock FROM categories WHERE id = ANY($1) ORDER BY id"
+	// Kenapa pq.Array? Driver pq butuh wrapper untuk mengirim slice Go sebagai array Postgres
+	rows, err := repo.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Slice kosong (bukan nil) agar JSON tetap [] jika tidak ada yang cocok
+	categories := make([]models.Category, 0)
+	for rows.Next() {
+		var c models.Category
+		err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.MinStock)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, nil
+}
+
+// GetByName mengambil satu kategori berdasarkan nama (case-insensitive, exact match)
+// Kenapa case-insensitive? Integrasi luar sering mengirim "minuman" atau "MINUMAN" untuk kategori "Minuman"
+// Kenapa return *models.Category? Sama seperti GetByID, pointer bisa nil jika tidak ditemukan
+func (repo *CategoryRepository) GetByName(name string) (*models.Category, error) {
+	// Kenapa LOWER(name) = LOWER($1) bukan ILIKE? ILIKE menganggap % dan _ sebagai wildcard, kita butuh exact match
+	query := "SELECT id, name, description, min_stock FROM categories WHERE LOWER(name) = LOWER($1) LIMIT 1"
+	var c models.Category
+	err := repo.db.QueryRow(query, name).Scan(&c.ID, &c.Name, &c.Description, &c.MinStock)
+	// Bedakan "data tidak ada" vs "error database", sama seperti GetByID
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "category"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
 // Create menambahkan kategori baru ke database
 // Kenapa parameter *models.Category? Pointer agar bisa update field ID setelah insert
 // Kenapa return error? Hanya perlu tahu berhasil atau gagal
@@ -112,12 +157,12 @@ func (repo *CategoryRepository) Create(category *models.Category) error {
 	// Query SQL untuk menyisipkan kategori baru ke dalam tabel categories
 	// Kenapa tidak INSERT id? Karena id auto-increment/serial, database yang generate
 	// Kenapa RETURNING id? Untuk mendapatkan ID yang baru saja di-generate oleh database
-	query := "INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id"
+	query := "INSERT INTO categories (name, description, min_stock) VALUES ($1, $2, $3) RETURNING id"
 	// Eksekusi query dengan QueryRow untuk mendapatkan ID yang di-generate
 	// Kenapa QueryRow? Karena RETURNING id mengembalikan 1 row berisi ID baru
 	// Kenapa Scan(&category.ID)? Untuk menyimpan ID yang di-return ke struct category
 	// Kenapa &category.ID? Pointer ke field ID agar bisa dimodifikasi (update by reference)
-	err := repo.db.QueryRow(query, category.Name, category.Description).Scan(&category.ID)
+	err := repo.db.QueryRow(query, category.Name, category.Description, category.MinStock).Scan(&category.ID)
 	// Kembalikan error (nil jika sukses, ada nilai jika gagal)
 	return err
 }
@@ -127,13 +172,13 @@ func (repo *CategoryRepository) Create(category *models.Category) error {
 // Kenapa return error? Untuk mengetahui apakah update berhasil atau gagal
 func (repo *CategoryRepository) Update(category *models.Category) error {
 	// Query SQL untuk memperbarui data kategori berdasarkan ID
-	// Kenapa SET name, description? Field yang akan di-update (tidak termasuk id karena primary key)
-	// Kenapa WHERE id = $3? Untuk memastikan hanya update kategori dengan ID tertentu
-	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3"
+	// Kenapa SET name, description, min_stock? Field yang akan di-update (tidak termasuk id karena primary key)
+	// Kenapa WHERE id = $4? Untuk memastikan hanya update kategori dengan ID tertentu
+	query := "UPDATE categories SET name = $1, description = $2, min_stock = $3 WHERE id = $4"
 	// Eksekusi query dengan Exec karena UPDATE tidak mengembalikan data, hanya result metadata
 	// Kenapa Exec bukan Query? UPDATE tidak mengembalikan rows data, hanya info berapa row affected
-	// Kenapa urutan parameter category.Name, Description, ID? Harus sesuai placeholder $1, $2, $3
-	result, err := repo.db.Exec(query, category.Name, category.Description, category.ID)
+	// Kenapa urutan parameter category.Name, Description, MinStock, ID? Harus sesuai placeholder $1, $2, $3, $4
+	result, err := repo.db.Exec(query, category.Name, category.Description, category.MinStock, category.ID)
 	// Cek apakah ada error saat eksekusi query (error koneksi, syntax, constraint, dll)
 	if err != nil {
 		// Kembalikan error jika query gagal dieksekusi
@@ -153,7 +198,7 @@ func (repo *CategoryRepository) Update(category *models.Category) error {
 	if rows == 0 {
 		// Kembalikan error custom untuk memberitahu bahwa kategori tidak ada
 		// Kenapa error custom? Agar client tahu penyebab spesifik: data tidak ditemukan
-		return errors.New("category not found")
+		return &NotFoundError{Resource: "category", ID: category.ID}
 	}
 	// Kembalikan nil jika update berhasil (minimal 1 baris terpengaruh)
 	// Kenapa return nil? nil = no error = success
@@ -191,7 +236,7 @@ func (repo *CategoryRepository) Delete(id int) error {
 	if rows == 0 {
 		// Kembalikan error custom untuk memberitahu bahwa kategori tidak ada
 		// Kenapa error custom? Agar client tahu penyebab spesifik: data tidak ditemukan
-		return errors.New("category not found")
+		return &NotFoundError{Resource: "category", ID: id}
 	}
 	// Kembalikan nil jika delete berhasil (minimal 1 baris terhapus)
 	// Kenapa return nil? nil = no error = success
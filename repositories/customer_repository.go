@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+)
+
+// CustomerRepository mengelola operasi database untuk tabel customers
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+// NewCustomerRepository membuat instance baru dari CustomerRepository
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+// GetByPhone mengambil pelanggan berdasarkan nomor HP
+func (repo *CustomerRepository) GetByPhone(phone string) (*models.Customer, error) {
+	query := "SELECT id, phone, name, points, created_at FROM customers WHERE phone = $1"
+
+	var c models.Customer
+	err := repo.db.QueryRow(query, phone).Scan(&c.ID, &c.Phone, &c.Name, &c.Points, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "customer"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
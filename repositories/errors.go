@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// NotFoundError dikembalikan ketika data yang dicari tidak ada di database
+// Resource dan ID dipakai handler untuk membuat response 404 yang terstruktur
+type NotFoundError struct {
+	Resource string
+	ID       int
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found", e.Resource)
+}
+
+// ConflictError dikembalikan ketika data melanggar aturan unik (misal SKU sudah dipakai produk lain)
+// Handler memetakan error ini ke response 409
+type ConflictError struct {
+	Resource string
+	Field    string
+	Value    string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s with %s %s already exists", e.Resource, e.Field, e.Value)
+}
+
+// InsufficientStockError dikembalikan checkout ketika qty yang diminta melebihi stok produk
+// Handler memetakan error ini ke response 400
+type InsufficientStockError struct {
+	ProductID   int
+	ProductName string
+	Have        int
+	Need        int
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("insufficient stock for product %s: have %d, need %d", e.ProductName, e.Have, e.Need)
+}
+
+// StockHistoryError dikembalikan saat mengisi stok awal produk yang sudah punya riwayat stock movement
+// Saldo awal hanya boleh diganti selama movement-nya baru opening_balance dari pembuatan produk
+type StockHistoryError struct {
+	ProductID int
+}
+
+func (e *StockHistoryError) Error() string {
+	return fmt.Sprintf("product %d already has stock movements recorded", e.ProductID)
+}
+
+// CheckoutRejectedError dikembalikan checkout ketika request melanggar aturan transaksi
+// (diskon melebihi total, poin tidak cukup, pembayaran kurang); service memetakannya ke error validasi
+type CheckoutRejectedError struct {
+	Reason string
+}
+
+func (e *CheckoutRejectedError) Error() string {
+	return e.Reason
+}
+
+// DiscountLimitError dikembalikan checkout ketika diskon melebihi MAX_DISCOUNT_PERCENT
+// ProductName kosong berarti diskon transaksi (Base = subtotal), selain itu diskon item (Base = harga * qty)
+// Handler memetakan error ini ke response 403
+type DiscountLimitError struct {
+	ProductName string
+	Discount    int
+	Base        int
+	MaxPercent  float64
+}
+
+func (e *DiscountLimitError) Error() string {
+	if e.ProductName != "" {
+		return fmt.Sprintf("discount %d for product %s exceeds %g%% of the line total %d", e.Discount, e.ProductName, e.MaxPercent, e.Base)
+	}
+	return fmt.Sprintf("discount %d exceeds %g%% of the subtotal %d", e.Discount, e.MaxPercent, e.Base)
+}
+
+// checkoutRejected membuat CheckoutRejectedError dengan format seperti fmt.Errorf
+func checkoutRejected(format string, args ...interface{}) error {
+	return &CheckoutRejectedError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// ErrDuplicateIdempotencyKey dikembalikan checkout ketika transaksi dengan idempotency key yang sama
+// sudah tersimpan oleh request lain yang berjalan bersamaan; seluruh perubahan checkout ini sudah di-rollback
+var ErrDuplicateIdempotencyKey = errors.New("idempotency key already used")
+
+// ErrStockAlreadyZero dikembalikan write-off ketika stok produk sudah 0 atau kurang
+var ErrStockAlreadyZero = errors.New("stock is already zero")
+
+// ErrTransactionCancelled dikembalikan saat membatalkan transaksi yang sudah dibatalkan atau di-void
+var ErrTransactionCancelled = errors.New("transaction is already cancelled")
+
+// isUniqueViolation memeriksa apakah err berasal dari pelanggaran unique constraint di PostgreSQL (kode 23505)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// uniqueViolationConstraint mengembalikan nama constraint/index yang dilanggar, kosong jika err bukan unique violation
+// Dipakai untuk membedakan kolom mana yang bentrok pada tabel dengan lebih dari satu unique index
+func uniqueViolationConstraint(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/models"
+	"strings"
+)
+
+// ImportRepository mengelola import katalog (kategori + produk) dalam satu transaksi
+type ImportRepository struct {
+	db *sql.DB
+}
+
+// NewImportRepository membuat instance baru dari ImportRepository
+func NewImportRepository(db *sql.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+// ImportCatalog membuat kategori terlebih dahulu, lalu produk dengan category_name yang di-resolve ke ID
+// Kategori yang namanya sudah ada dipakai ulang, produk dengan kategori tidak dikenal dicatat sebagai error
+// Semua insert ada di satu transaksi: jika ada error database, tidak ada data yang tersimpan
+func (repo *ImportRepository) ImportCatalog(req *models.ImportRequest) (*models.ImportResult, error) {
+	result := &models.ImportResult{Errors: make([]string, 0)}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// categoryIDs memetakan nama kategori (lowercase) ke ID
+	categoryIDs := make(map[string]int)
+	// Index error memakai Row (posisi asli di dokumen), bukan index slice yang sudah difilter service
+	for _, c := range req.Categories {
+		key := strings.ToLower(c.Name)
+		if _, ok := categoryIDs[key]; ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("categories[%d]: duplicate name %s", c.Row, c.Name))
+			continue
+		}
+
+		var id int
+		err := tx.QueryRow("SELECT id FROM categories WHERE LOWER(name) = $1 LIMIT 1", key).Scan(&id)
+		if err == nil {
+			categoryIDs[key] = id
+			continue
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		err = tx.QueryRow("INSERT INTO categories (name, description, min_stock) VALUES ($1, $2, $3) RETURNING id",
+			c.Name, c.Description, c.MinStock).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		categoryIDs[key] = id
+		result.CategoriesCreated++
+	}
+
+	for _, p := range req.Products {
+		var categoryID *int
+		if p.CategoryName != "" {
+			key := strings.ToLower(p.CategoryName)
+			id, ok := categoryIDs[key]
+			if !ok {
+				// Kategori tidak ada di dokumen import, coba cari yang sudah ada di database
+				err := tx.QueryRow("SELECT id FROM categories WHERE LOWER(name) = $1 LIMIT 1", key).Scan(&id)
+				if err == sql.ErrNoRows {
+					result.Errors = append(result.Errors, fmt.Sprintf("products[%d]: category %s not found", p.Row, p.CategoryName))
+					continue
+				}
+				if err != nil {
+					return nil, err
+				}
+				categoryIDs[key] = id
+			}
+			categoryID = &id
+		}
+
+		// Savepoint agar nama produk yang sudah ada (unique index) cukup dicatat sebagai error baris
+		// tanpa membatalkan seluruh transaksi import
+		if _, err := tx.Exec("SAVEPOINT import_product"); err != nil {
+			return nil, err
+		}
+		// insertProduct yang sama dengan create biasa agar opening_balance dan audit log ikut tercatat
+		product := models.Product{Name: p.Name, Price: p.Price, Stock: p.Stock, CategoryID: categoryID}
+		err := insertProduct(tx, &product)
+		var conflict *ConflictError
+		if errors.As(err, &conflict) {
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT import_product"); err != nil {
+				return nil, err
+			}
+			result.Errors = append(result.Errors, fmt.Sprintf("products[%d]: %v", p.Row, conflict))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec("RELEASE SAVEPOINT import_product"); err != nil {
+			return nil, err
+		}
+		result.ProductsCreated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
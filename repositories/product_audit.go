@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"kasir-api/models"
+)
+
+// Jenis aksi yang dicatat di product_audit_logs
+const (
+	AuditCreate        = "create"
+	AuditUpdate        = "update"
+	AuditPatch         = "patch"
+	AuditDelete        = "delete"
+	AuditStockMovement = "stock_movement"
+	AuditFavorite      = "favorite"
+)
+
+// execer diimplementasikan oleh *sql.DB dan *sql.Tx
+// Audit sebaiknya ditulis di transaksi yang sama dengan perubahannya agar tidak ada perubahan tanpa jejak
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// recordAudit mencatat satu perubahan produk beserta payload-nya dalam bentuk JSON
+func recordAudit(db execer, productID int, action string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("INSERT INTO product_audit_logs (product_id, action, payload) VALUES ($1, $2, $3)",
+		productID, action, data)
+	return err
+}
+
+// GetAuditTrail mengambil seluruh riwayat perubahan produk secara kronologis (terlama di awal)
+// Riwayat tetap ada walaupun produk sudah dihapus
+func (repo *ProductRepository) GetAuditTrail(id int) ([]models.ProductAudit, error) {
+	rows, err := repo.db.Query(`
+		SELECT id, product_id, action, payload, created_at
+		FROM product_audit_logs
+		WHERE product_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	audits := make([]models.ProductAudit, 0)
+	for rows.Next() {
+		var a models.ProductAudit
+		var payload []byte
+		if err := rows.Scan(&a.ID, &a.ProductID, &a.Action, &payload, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.Payload = json.RawMessage(payload)
+		audits = append(audits, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Tanpa riwayat dan produknya juga tidak ada berarti ID memang tidak dikenal
+	if len(audits) == 0 {
+		var exists bool
+		err := repo.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", id).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, &NotFoundError{Resource: "product", ID: id}
+		}
+	}
+
+	return audits, nil
+}
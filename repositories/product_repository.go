@@ -2,10 +2,60 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
+	"fmt"
 	"kasir-api/models"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
+// effectiveMinStock adalah batas stok minimum yang berlaku: min_stock produk, jika NULL pakai min_stock kategori
+// Dipakai semua query yang menentukan low stock (stock status, alert checkout, stock levels, KPI, reorder)
+// sehingga query tersebut harus join categories dengan alias c
+const effectiveMinStock = "COALESCE(p.min_stock, c.min_stock)"
+
+// returningEffectiveMinStock adalah effectiveMinStock untuk klausa RETURNING pada INSERT/UPDATE products (tanpa join)
+const returningEffectiveMinStock = "COALESCE(min_stock, (SELECT c.min_stock FROM categories c WHERE c.id = category_id))"
+
+// productColumns adalah daftar kolom produk yang dibaca oleh scanProduct (urutannya harus sama)
+const productColumns = `p.id, p.name, COALESCE(p.sku, ''), p.price, p.cost_price, p.stock, p.min_stock, ` + effectiveMinStock + `, p.expiry_date, p.is_favorite, p.is_tax_exempt, p.category_id, COALESCE(c.name, '') as category_name, p.created_at, p.updated_at`
+
+// productSelectQuery adalah SELECT dasar produk beserta nama kategorinya
+// Dipakai bersama oleh semua query yang mengembalikan models.Product agar kolomnya selalu sama dengan scanProduct
+const productSelectQuery = `
+	SELECT ` + productColumns + `
+	FROM products p
+	LEFT JOIN categories c ON p.category_id = c.id
+	`
+
+// rowScanner diimplementasikan oleh *sql.Row dan *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct membaca satu baris hasil productSelectQuery ke dalam struct Product
+// extra dipakai untuk kolom tambahan yang diletakkan setelah productColumns
+func scanProduct(row rowScanner, p *models.Product, extra ...interface{}) error {
+	dest := []interface{}{&p.ID, &p.Name, &p.SKU, &p.Price, &p.CostPrice, &p.Stock, &p.MinStock, &p.EffectiveMinStock, &p.ExpiryDate, &p.IsFavorite, &p.IsTaxExempt, &p.CategoryID, &p.CategoryName, &p.CreatedAt, &p.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	p.Margin = p.Price - p.CostPrice
+	return nil
+}
+
+// ProductPatchableColumns adalah whitelist kolom yang boleh diubah lewat partial update
+// Nama kolom disusun ke dalam query, jadi hanya kolom di sini yang boleh dipakai (mencegah SQL injection)
+var ProductPatchableColumns = map[string]bool{
+	"name":          true,
+	"price":         true,
+	"stock":         true,
+	"min_stock":     true,
+	"is_tax_exempt": true,
+	"category_id":   true,
+}
+
 // ProductRepository mengelola operasi database untuk tabel products
 type ProductRepository struct {
 	db *sql.DB
@@ -16,54 +66,129 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-// GetAll mengambil semua data produk dari tabel products
-// Mengembalikan slice dari Product dan error jika ada
-func (repo *ProductRepository) GetAll(nameFilter string) ([]models.Product, error) {
-	query := `
-	SELECT p.id, p.name, p.price, p.stock, p.category_id, COALESCE(c.name, '') as category_name
-	FROM products p
-	LEFT JOIN categories c ON p.category_id = c.id
-	`
+// ProductSortOrders adalah whitelist nilai ?sort= pada daftar produk beserta klausa ORDER BY-nya
+var ProductSortOrders = map[string]string{
+	"id_asc":     "p.id ASC",
+	"name_asc":   "p.name ASC, p.id ASC",
+	"name_desc":  "p.name DESC, p.id ASC",
+	"price_asc":  "p.price ASC, p.id ASC",
+	"price_desc": "p.price DESC, p.id ASC",
+	"stock_asc":  "p.stock ASC, p.id ASC",
+	"stock_desc": "p.stock DESC, p.id ASC",
+}
+
+// GetAll mengambil satu halaman data produk dari tabel products sesuai filter
+// Mengembalikan slice dari Product, total produk yang cocok dengan filter, dan error jika ada
+func (repo *ProductRepository) GetAll(filter models.ProductFilter, limit, offset int) ([]models.Product, int, error) {
+	sort := filter.Sort
+	if sort == "" {
+		sort = "id_asc"
+	}
+	// Nilai sort hanya boleh dari whitelist karena ORDER BY tidak bisa memakai placeholder
+	orderBy, ok := ProductSortOrders[sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid sort %s", sort)
+	}
+
+	// Kondisi WHERE hanya ditambahkan untuk filter yang diisi, nilai selalu lewat placeholder
+	conditions := make([]string, 0)
 	args := []interface{}{}
-	if nameFilter != "" {
-		query += " WHERE p.name ILIKE $1"
-		args = append(args, "%"+nameFilter+"%")
+	if filter.Name != "" {
+		args = append(args, "%"+filter.Name+"%")
+		conditions = append(conditions, fmt.Sprintf("p.name ILIKE $%d", len(args)))
+	}
+	if filter.CategoryID != nil {
+		args = append(args, *filter.CategoryID)
+		conditions = append(conditions, fmt.Sprintf("p.category_id = $%d", len(args)))
+	}
+	if filter.MinPrice != nil {
+		args = append(args, *filter.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("p.price >= $%d", len(args)))
+	}
+	if filter.MaxPrice != nil {
+		args = append(args, *filter.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("p.price <= $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Total dihitung terpisah dengan filter yang sama agar frontend bisa membuat kontrol pagination
+	var total int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM products p"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
 	}
 
+	// ORDER BY wajib agar isi setiap halaman konsisten, p.id sebagai tie-breaker untuk nilai yang sama
+	query := productSelectQuery + where + fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", orderBy, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
 	rows, err := repo.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	products := make([]models.Product, 0)
 	for rows.Next() {
 		var p models.Product
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName)
+		err := scanProduct(rows, &p)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		products = append(products, p)
 	}
-	return products, nil
+	return products, total, nil
 }
 
 // GetByID mengambil satu produk berdasarkan ID dari database
 // Mengembalikan pointer ke Product dan error jika produk tidak ditemukan
 func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
-	query := `
-	SELECT p.id, p.name, p.price, p.stock, p.category_id, COALESCE(c.name, '') as category_name
-	FROM products p
-	LEFT JOIN categories c ON p.category_id = c.id
-	WHERE p.id = $1`
+	query := productSelectQuery + " WHERE p.id = $1"
 
 	var p models.Product
-	err := repo.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &p.CategoryName)
+	err := scanProduct(repo.db.QueryRow(query, id), &p)
+
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "product", ID: id}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
 
+// GetBySKU mengambil satu produk berdasarkan SKU/barcode
+func (repo *ProductRepository) GetBySKU(sku string) (*models.Product, error) {
+	query := productSelectQuery + " WHERE p.sku = $1"
+
+	var p models.Product
+	err := scanProduct(repo.db.QueryRow(query, sku), &p)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("product not found")
+		return nil, &NotFoundError{Resource: "product"}
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// GetByName mengambil produk dengan nama yang sama tanpa membedakan huruf besar/kecil
+// Mengembalikan NotFoundError jika tidak ada produk dengan nama tersebut
+func (repo *ProductRepository) GetByName(name string) (*models.Product, error) {
+	query := productSelectQuery + " WHERE LOWER(p.name) = LOWER($1) ORDER BY p.id LIMIT 1"
 
+	var p models.Product
+	err := scanProduct(repo.db.QueryRow(query, name), &p)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "product"}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -71,40 +196,138 @@ func (repo *ProductRepository) GetByID(id int) (*models.Product, error) {
 	return &p, nil
 }
 
+// productConflict mengubah unique violation pada tabel products menjadi ConflictError untuk kolom yang bentrok
+func productConflict(err error, name, sku string) error {
+	if uniqueViolationConstraint(err) == "idx_products_name_lower" {
+		return &ConflictError{Resource: "product", Field: "name", Value: name}
+	}
+	return &ConflictError{Resource: "product", Field: "sku", Value: sku}
+}
+
 // Create menambahkan produk baru ke database
 // Mengisi field ID pada product dengan ID yang di-generate oleh database
 func (repo *ProductRepository) Create(product *models.Product) error {
-	query := "INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id"
-	err := repo.db.QueryRow(query, product.Name, product.Price, product.Stock, product.CategoryID).Scan(&product.ID)
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertProduct(tx, product); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateBatch menambahkan banyak produk dalam satu transaksi
+// Jika satu produk gagal disimpan, semua produk di batch dibatalkan
+func (repo *ProductRepository) CreateBatch(products []models.Product) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range products {
+		if err := insertProduct(tx, &products[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertProduct menyimpan satu produk beserta audit log-nya di dalam transaksi tx
+// Mengisi ID, CreatedAt, dan UpdatedAt dari database
+func insertProduct(tx *sql.Tx, product *models.Product) error {
+	// SKU kosong disimpan sebagai NULL agar tidak bentrok dengan unique index
+	query := "INSERT INTO products (name, sku, price, cost_price, stock, min_stock, expiry_date, is_tax_exempt, category_id) VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, " + returningEffectiveMinStock
+	err := tx.QueryRow(query, product.Name, product.SKU, product.Price, product.CostPrice, product.Stock, product.MinStock, product.ExpiryDate, product.IsTaxExempt, product.CategoryID).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt, &product.EffectiveMinStock)
+	if isUniqueViolation(err) {
+		return productConflict(err, product.Name, product.SKU)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Stok awal dicatat sebagai opening_balance agar produk bisa direkonsiliasi dari riwayat movement
+	if err := insertStockMovement(tx, product.ID, product.Stock, ReasonOpeningBalance); err != nil {
+		return err
+	}
+
+	return recordAudit(tx, product.ID, AuditCreate, product)
+}
+
+// ReasonStockSet adalah alasan stock movement saat stok diubah langsung lewat PUT/PATCH produk
+const ReasonStockSet = "stock_set"
+
+// insertStockMovement mencatat perubahan stok di stock_movements di dalam transaksi tx
+func insertStockMovement(tx *sql.Tx, productID, delta int, reason string) error {
+	_, err := tx.Exec("INSERT INTO stock_movements (product_id, delta, reason) VALUES ($1, $2, $3)", productID, delta, reason)
 	return err
 }
 
+// recordStockSet mencatat selisih stok sebagai movement stock_set saat stok di-set langsung ke nilai baru
+// Mengunci baris produk dan mengembalikan NotFoundError jika produk tidak ada
+func recordStockSet(tx *sql.Tx, productID, newStock int) error {
+	var current int
+	err := tx.QueryRow("SELECT stock FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Resource: "product", ID: productID}
+	}
+	if err != nil {
+		return err
+	}
+	if newStock == current {
+		return nil
+	}
+	return insertStockMovement(tx, productID, newStock-current, ReasonStockSet)
+}
+
 // Update memperbarui data produk yang sudah ada di database
 // Mengembalikan error jika produk dengan ID tersebut tidak ditemukan
 func (repo *ProductRepository) Update(product *models.Product) error {
-	query := "UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5"
-	result, err := repo.db.Exec(query, product.Name, product.Price, product.Stock, product.CategoryID, product.ID)
+	tx, err := repo.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	if err := recordStockSet(tx, product.ID, product.Stock); err != nil {
+		return err
+	}
+
+	query := "UPDATE products SET name = $1, sku = NULLIF($2, ''), price = $3, cost_price = $4, stock = $5, min_stock = $6, expiry_date = $7, is_tax_exempt = $8, category_id = $9, updated_at = NOW() WHERE id = $10 RETURNING created_at, updated_at, " + returningEffectiveMinStock
+	err = tx.QueryRow(query, product.Name, product.SKU, product.Price, product.CostPrice, product.Stock, product.MinStock, product.ExpiryDate, product.IsTaxExempt, product.CategoryID, product.ID).Scan(&product.CreatedAt, &product.UpdatedAt, &product.EffectiveMinStock)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Resource: "product", ID: product.ID}
+	}
+	if isUniqueViolation(err) {
+		return productConflict(err, product.Name, product.SKU)
+	}
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("product not found")
+	if err := recordAudit(tx, product.ID, AuditUpdate, product); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 // Delete menghapus produk dari database berdasarkan ID
 // Mengembalikan error jika produk dengan ID tersebut tidak ditemukan
 func (repo *ProductRepository) Delete(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := "DELETE FROM products WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+	result, err := tx.Exec(query, id)
 
 	if err != nil {
 		return err
@@ -116,8 +339,671 @@ func (repo *ProductRepository) Delete(id int) error {
 	}
 
 	if rows == 0 {
-		return errors.New("product not found")
+		return &NotFoundError{Resource: "product", ID: id}
 	}
 
-	return nil
+	if err := recordAudit(tx, id, AuditDelete, struct{}{}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PartialUpdate mengubah hanya kolom yang ada di fields untuk satu produk
+// Kolom di luar ProductPatchableColumns ditolak, mengembalikan NotFoundError jika produk tidak ada
+func (repo *ProductRepository) PartialUpdate(id int, fields map[string]interface{}) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := applyProductPatch(tx, id, fields); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// applyProductPatch menyusun dan menjalankan UPDATE ... SET dinamis dari fields lalu mencatat audit patch
+// Dipakai bersama oleh PartialUpdate dan BatchPartialUpdate
+func applyProductPatch(tx *sql.Tx, id int, fields map[string]interface{}) error {
+	// Urutkan nama kolom agar query yang dihasilkan selalu sama untuk input yang sama
+	columns := make([]string, 0, len(fields))
+	for column := range fields {
+		if !ProductPatchableColumns[column] {
+			return fmt.Errorf("field %s cannot be updated", column)
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	if stock, ok := fields["stock"].(int); ok {
+		if err := recordStockSet(tx, id, stock); err != nil {
+			return err
+		}
+	}
+
+	sets := make([]string, 0, len(columns))
+	args := make([]interface{}, 0, len(columns)+1)
+	for i, column := range columns {
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, i+1))
+		args = append(args, fields[column])
+	}
+	args = append(args, id)
+
+	query := fmt.Sprintf("UPDATE products SET %s, updated_at = NOW() WHERE id = $%d", strings.Join(sets, ", "), len(args))
+	result, err := tx.Exec(query, args...)
+	if isUniqueViolation(err) {
+		name, _ := fields["name"].(string)
+		return productConflict(err, name, "")
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return &NotFoundError{Resource: "product", ID: id}
+	}
+
+	return recordAudit(tx, id, AuditPatch, fields)
+}
+
+// BatchPartialUpdate menerapkan beberapa partial update produk dalam satu transaksi database
+// Jika salah satu gagal (misal ID tidak ditemukan), semua perubahan di-rollback
+// Mengembalikan produk-produk setelah diupdate sesuai urutan input
+func (repo *ProductRepository) BatchPartialUpdate(patches []models.ProductPatch) ([]models.Product, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	products := make([]models.Product, 0, len(patches))
+	for _, patch := range patches {
+		if err := applyProductPatch(tx, patch.ID, patch.Fields); err != nil {
+			return nil, err
+		}
+
+		var p models.Product
+		err = scanProduct(tx.QueryRow(productSelectQuery+" WHERE p.id = $1", patch.ID), &p)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// BulkSetMinStock mengubah min_stock beberapa produk dalam satu transaksi (all-or-nothing)
+// Jika salah satu produk tidak ditemukan, tidak ada perubahan yang disimpan
+func (repo *ProductRepository) BulkSetMinStock(updates []models.MinStockUpdate) (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, u := range updates {
+		result, err := tx.Exec("UPDATE products SET min_stock = $1, updated_at = NOW() WHERE id = $2", *u.MinStock, u.ProductID)
+		if err != nil {
+			return 0, err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rows == 0 {
+			return 0, &NotFoundError{Resource: "product", ID: u.ProductID}
+		}
+
+		if err := recordAudit(tx, u.ProductID, AuditPatch, map[string]int{"min_stock": *u.MinStock}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(updates), nil
+}
+
+// SetMinStockByCategory mengubah min_stock semua produk dalam kategori dan mengembalikan jumlah produk yang berubah
+func (repo *ProductRepository) SetMinStockByCategory(categoryID, minStock int) (int, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1)", categoryID).Scan(&exists)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, &NotFoundError{Resource: "category", ID: categoryID}
+	}
+
+	rows, err := tx.Query("UPDATE products SET min_stock = $1, updated_at = NOW() WHERE category_id = $2 RETURNING id", minStock, categoryID)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, id := range ids {
+		if err := recordAudit(tx, id, AuditPatch, map[string]int{"min_stock": minStock}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(ids), nil
+}
+
+// WriteOff menghapus seluruh stok produk (rusak, kadaluarsa, dll) dan mencatatnya sebagai stock movement
+// Dijalankan dalam satu transaksi agar stok dan catatan movement selalu konsisten
+func (repo *ProductRepository) WriteOff(id int, reason string) (*models.StockMovement, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// FOR UPDATE agar stok tidak berubah oleh checkout lain sebelum write-off selesai
+	var stock int
+	err = tx.QueryRow("SELECT stock FROM products WHERE id = $1 FOR UPDATE", id).Scan(&stock)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "product", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stock <= 0 {
+		return nil, ErrStockAlreadyZero
+	}
+
+	_, err = tx.Exec("UPDATE products SET stock = 0 WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	movement := models.StockMovement{
+		ProductID: id,
+		Delta:     -stock,
+		Reason:    reason,
+	}
+	err = tx.QueryRow("INSERT INTO stock_movements (product_id, delta, reason) VALUES ($1, $2, $3) RETURNING id, created_at",
+		movement.ProductID, movement.Delta, movement.Reason).Scan(&movement.ID, &movement.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(tx, id, AuditStockMovement, movement); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &movement, nil
+}
+
+// AdjustStock menambah atau mengurangi stok sebesar delta dan mencatatnya di stock_movements dalam satu transaksi
+// Koreksi yang membuat stok menjadi negatif ditolak dengan InsufficientStockError
+func (repo *ProductRepository) AdjustStock(id, delta int, reason string) (*models.StockAdjustment, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// FOR UPDATE agar stok tidak berubah oleh checkout lain di antara pengecekan dan update
+	var name string
+	var stock int
+	err = tx.QueryRow("SELECT name, stock FROM products WHERE id = $1 FOR UPDATE", id).Scan(&name, &stock)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "product", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stock+delta < 0 {
+		return nil, &InsufficientStockError{ProductID: id, ProductName: name, Have: stock, Need: -delta}
+	}
+
+	adjustment := models.StockAdjustment{
+		StockMovement: models.StockMovement{ProductID: id, Delta: delta, Reason: reason},
+	}
+	err = tx.QueryRow("UPDATE products SET stock = stock + $1 WHERE id = $2 RETURNING stock", delta, id).Scan(&adjustment.Stock)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRow("INSERT INTO stock_movements (product_id, delta, reason) VALUES ($1, $2, $3) RETURNING id, created_at",
+		id, delta, reason).Scan(&adjustment.ID, &adjustment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(tx, id, AuditStockMovement, adjustment.StockMovement); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &adjustment, nil
+}
+
+// ReasonOpeningBalance adalah alasan stock movement untuk saldo stok awal
+const ReasonOpeningBalance = "opening_balance"
+
+// SetOpeningStock mengisi stok awal beberapa produk dan mencatat stock movement opening_balance
+// Ditolak dengan StockHistoryError jika produk sudah punya stock movement selain opening_balance saat produk dibuat
+// Delta opening_balance adalah saldo awal penuh karena rekonsiliasi menghitung riwayat stok mulai dari movement ini
+func (repo *ProductRepository) SetOpeningStock(items []models.OpeningStockItem) ([]models.StockMovement, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	movements := make([]models.StockMovement, 0, len(items))
+	for _, item := range items {
+		var current int
+		err := tx.QueryRow("SELECT stock FROM products WHERE id = $1 FOR UPDATE", item.ProductID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return nil, &NotFoundError{Resource: "product", ID: item.ProductID}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Satu-satunya movement yang boleh ada adalah opening_balance yang ditulis saat produk dibuat,
+		// movement itu diganti dengan saldo awal yang baru
+		var openings, others int
+		err = tx.QueryRow(`
+			SELECT COUNT(*) FILTER (WHERE reason = $2), COUNT(*) FILTER (WHERE reason <> $2)
+			FROM stock_movements WHERE product_id = $1`, item.ProductID, ReasonOpeningBalance).Scan(&openings, &others)
+		if err != nil {
+			return nil, err
+		}
+		if openings > 1 || others > 0 {
+			return nil, &StockHistoryError{ProductID: item.ProductID}
+		}
+
+		_, err = tx.Exec("DELETE FROM stock_movements WHERE product_id = $1 AND reason = $2", item.ProductID, ReasonOpeningBalance)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.Exec("UPDATE products SET stock = $1 WHERE id = $2", *item.Stock, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		movement := models.StockMovement{
+			ProductID: item.ProductID,
+			Delta:     *item.Stock,
+			Reason:    ReasonOpeningBalance,
+		}
+		err = tx.QueryRow("INSERT INTO stock_movements (product_id, delta, reason) VALUES ($1, $2, $3) RETURNING id, created_at",
+			movement.ProductID, movement.Delta, movement.Reason).Scan(&movement.ID, &movement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := recordAudit(tx, item.ProductID, AuditStockMovement, movement); err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
+
+// AuditStockReconcile dicatat saat stok dikoreksi oleh rekonsiliasi
+const AuditStockReconcile = "stock_reconcile"
+
+// ReconcileStock menghitung ulang stok dari riwayat (stock movements dikurangi qty terjual) sejak saldo awal
+// Hanya produk yang punya movement opening_balance yang diperiksa, karena tanpa saldo awal riwayatnya tidak lengkap
+// Jika fix true, stok yang berbeda dikoreksi ke nilai hasil hitung ulang dalam transaksi yang sama
+func (repo *ProductRepository) ReconcileStock(fix bool) (*models.StockReconcileResult, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Penjualan mengurangi stok tanpa stock movement, transaksi void dikembalikan lewat movement "void"
+	// sehingga qty terjual dihitung dari semua transaksi sejak saldo awal termasuk yang sudah void
+	query := `
+		WITH opening AS (
+			SELECT product_id, MIN(created_at) AS opened_at
+			FROM stock_movements
+			WHERE reason = $1
+			GROUP BY product_id
+		),
+		movements AS (
+			SELECT sm.product_id, SUM(sm.delta) AS total
+			FROM stock_movements sm
+			JOIN opening o ON o.product_id = sm.product_id
+			WHERE sm.created_at >= o.opened_at
+			GROUP BY sm.product_id
+		),
+		sold AS (
+			SELECT td.product_id, SUM(td.quantity) AS total
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			JOIN opening o ON o.product_id = td.product_id
+			WHERE t.created_at >= o.opened_at
+			GROUP BY td.product_id
+		)
+		SELECT p.id, p.name, p.stock, m.total - COALESCE(s.total, 0)
+		FROM products p
+		JOIN movements m ON m.product_id = p.id
+		LEFT JOIN sold s ON s.product_id = p.id
+		ORDER BY p.id`
+	if fix {
+		query += " FOR UPDATE OF p"
+	}
+
+	rows, err := tx.Query(query, ReasonOpeningBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.StockReconcileResult{Fixed: fix, Discrepancies: make([]models.StockDiscrepancy, 0)}
+	for rows.Next() {
+		var d models.StockDiscrepancy
+		if err := rows.Scan(&d.ProductID, &d.Name, &d.RecordedStock, &d.ExpectedStock); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result.Checked++
+		if d.RecordedStock != d.ExpectedStock {
+			d.Difference = d.RecordedStock - d.ExpectedStock
+			result.Discrepancies = append(result.Discrepancies, d)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !fix {
+		return result, nil
+	}
+
+	// Koreksi tidak ditulis sebagai stock movement karena movement adalah sumber kebenaran yang dipakai menghitung ulang
+	for _, d := range result.Discrepancies {
+		_, err := tx.Exec("UPDATE products SET stock = $1 WHERE id = $2", d.ExpectedStock, d.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if err := recordAudit(tx, d.ProductID, AuditStockReconcile, d); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetSalesVelocity mengambil stok saat ini dan total unit terjual dalam N hari terakhir
+func (repo *ProductRepository) GetSalesVelocity(id, days int) (*models.ProductVelocity, error) {
+	velocity := models.ProductVelocity{ProductID: id, Days: days}
+
+	err := repo.db.QueryRow("SELECT stock FROM products WHERE id = $1", id).Scan(&velocity.Stock)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "product", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.db.QueryRow(`
+		SELECT COALESCE(SUM(td.quantity), 0)
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		WHERE td.product_id = $1 AND t.voided_at IS NULL AND t.created_at >= NOW() - make_interval(days => $2)
+	`, id, days).Scan(&velocity.TotalSold)
+	if err != nil {
+		return nil, err
+	}
+
+	return &velocity, nil
+}
+
+// GetSalesTrend mengambil qty dan revenue produk untuk N periode terakhir termasuk periode berjalan
+// Periode tanpa penjualan tetap muncul dengan nilai 0
+func (repo *ProductRepository) GetSalesTrend(id int, period string, count int, tz string) ([]models.TrendPoint, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &NotFoundError{Resource: "product", ID: id}
+	}
+
+	return querySalesTrend(repo.db, "td.product_id", id, period, count, tz)
+}
+
+// GetExpiring mengambil produk yang kadaluarsa dalam N hari ke depan (termasuk yang sudah lewat)
+// Produk tanpa expiry_date tidak ikut, diurutkan dari yang paling cepat kadaluarsa
+func (repo *ProductRepository) GetExpiring(days int) ([]models.Product, error) {
+	query := productSelectQuery + `
+	WHERE p.expiry_date IS NOT NULL AND p.expiry_date <= CURRENT_DATE + $1::int
+	ORDER BY p.expiry_date ASC, p.id ASC`
+
+	rows, err := repo.db.Query(query, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0)
+	for rows.Next() {
+		var p models.Product
+		err := scanProduct(rows, &p)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+// GetFavorites mengambil semua produk favorit, diurutkan berdasarkan nama agar posisinya stabil di layar POS
+func (repo *ProductRepository) GetFavorites() ([]models.Product, error) {
+	query := productSelectQuery + " WHERE p.is_favorite = TRUE ORDER BY p.name ASC, p.id ASC"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0)
+	for rows.Next() {
+		var p models.Product
+		err := scanProduct(rows, &p)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+// GetLowStock mengambil produk dengan stok <= threshold, stok paling sedikit di awal
+func (repo *ProductRepository) GetLowStock(threshold int) ([]models.Product, error) {
+	query := productSelectQuery + " WHERE p.stock <= $1 ORDER BY p.stock ASC, p.id ASC"
+
+	rows, err := repo.db.Query(query, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0)
+	for rows.Next() {
+		var p models.Product
+		err := scanProduct(rows, &p)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+// GetOutOfStock mengambil produk yang stoknya habis (stock <= 0), diurutkan berdasarkan nama
+func (repo *ProductRepository) GetOutOfStock() ([]models.Product, error) {
+	query := productSelectQuery + " WHERE p.stock <= 0 ORDER BY p.name ASC, p.id ASC"
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0)
+	for rows.Next() {
+		var p models.Product
+		err := scanProduct(rows, &p)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+// ToggleFavorite membalik status favorit produk
+// Mengembalikan error jika produk dengan ID tersebut tidak ditemukan
+func (repo *ProductRepository) ToggleFavorite(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var isFavorite bool
+	err = tx.QueryRow("UPDATE products SET is_favorite = NOT is_favorite, updated_at = NOW() WHERE id = $1 RETURNING is_favorite", id).Scan(&isFavorite)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Resource: "product", ID: id}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := recordAudit(tx, id, AuditFavorite, map[string]bool{"is_favorite": isFavorite}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetAllWithCategory mengambil semua produk beserta deskripsi kategorinya dalam satu query
+// Diurutkan per kategori (produk tanpa kategori di akhir) agar mudah dikelompokkan di service
+func (repo *ProductRepository) GetAllWithCategory() ([]models.Product, []string, error) {
+	query := `
+	SELECT ` + productColumns + `, COALESCE(c.description, '')
+	FROM products p
+	LEFT JOIN categories c ON p.category_id = c.id
+	ORDER BY c.name ASC NULLS LAST, c.id, p.name ASC, p.id ASC`
+
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0)
+	descriptions := make([]string, 0)
+	for rows.Next() {
+		var p models.Product
+		var description string
+		err := scanProduct(rows, &p, &description)
+		if err != nil {
+			return nil, nil, err
+		}
+		products = append(products, p)
+		descriptions = append(descriptions, description)
+	}
+	return products, descriptions, nil
+}
+
+// GetStockLevels mengambil stok dan min_stock efektif produk tanpa memuat seluruh kolom produk
+// ids kosong berarti semua produk, ID yang tidak ada diabaikan
+func (repo *ProductRepository) GetStockLevels(ids []int) ([]models.ProductStockStatus, error) {
+	query := "SELECT p.id, p.name, p.stock, " + effectiveMinStock + " FROM products p LEFT JOIN categories c ON p.category_id = c.id"
+	args := []interface{}{}
+	if len(ids) > 0 {
+		query += " WHERE p.id = ANY($1)"
+		args = append(args, pq.Array(ids))
+	}
+	query += " ORDER BY p.name ASC, p.id ASC"
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := make([]models.ProductStockStatus, 0)
+	for rows.Next() {
+		var l models.ProductStockStatus
+		var minStock sql.NullInt64
+		if err := rows.Scan(&l.ID, &l.Name, &l.Stock, &minStock); err != nil {
+			return nil, err
+		}
+		if minStock.Valid {
+			v := int(minStock.Int64)
+			l.MinStock = &v
+		}
+		levels = append(levels, l)
+	}
+	return levels, rows.Err()
 }
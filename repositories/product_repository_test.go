@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"errors"
+	"kasir-api/models"
+	"testing"
+)
+
+// Produk yang stok awalnya diisi saat create lalu dikoreksi lewat adjust-stock
+// tidak boleh dianggap selisih, dan ?fix=true tidak boleh mengubah stoknya
+func TestReconcileStockAfterCreateAndAdjust(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewProductRepository(db)
+
+	id := createTestProduct(t, db, 50)
+	if _, err := repo.AdjustStock(id, -2, "rusak"); err != nil {
+		t.Fatalf("adjust stock: %v", err)
+	}
+
+	result, err := repo.ReconcileStock(true)
+	if err != nil {
+		t.Fatalf("reconcile stock: %v", err)
+	}
+	for _, d := range result.Discrepancies {
+		if d.ProductID == id {
+			t.Fatalf("unexpected discrepancy for product %d: recorded %d, expected %d", id, d.RecordedStock, d.ExpectedStock)
+		}
+	}
+
+	product, err := repo.GetByID(id)
+	if err != nil {
+		t.Fatalf("get product: %v", err)
+	}
+	if product.Stock != 48 {
+		t.Fatalf("stock = %d, want 48", product.Stock)
+	}
+}
+
+// Stok yang di-set langsung lewat PATCH dicatat sebagai movement sehingga tetap cocok saat rekonsiliasi
+func TestReconcileStockAfterPatch(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewProductRepository(db)
+
+	id := createTestProduct(t, db, 10)
+	if err := repo.PartialUpdate(id, map[string]interface{}{"stock": 25}); err != nil {
+		t.Fatalf("patch stock: %v", err)
+	}
+
+	result, err := repo.ReconcileStock(false)
+	if err != nil {
+		t.Fatalf("reconcile stock: %v", err)
+	}
+	for _, d := range result.Discrepancies {
+		if d.ProductID == id {
+			t.Fatalf("unexpected discrepancy for product %d: recorded %d, expected %d", id, d.RecordedStock, d.ExpectedStock)
+		}
+	}
+}
+
+// Produk baru hanya punya opening_balance dari saat dibuat, jadi stok awalnya masih boleh diisi
+// Setelah ada movement lain (adjust-stock) pengisian stok awal ditolak dengan StockHistoryError
+func TestSetOpeningStockAfterCreate(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewProductRepository(db)
+
+	id := createTestProduct(t, db, 5)
+	stock := 40
+	movements, err := repo.SetOpeningStock([]models.OpeningStockItem{{ProductID: id, Stock: &stock}})
+	if err != nil {
+		t.Fatalf("set opening stock: %v", err)
+	}
+	if len(movements) != 1 || movements[0].Delta != 40 {
+		t.Fatalf("movements = %+v, want one opening_balance with delta 40", movements)
+	}
+
+	var openings int
+	err = db.QueryRow("SELECT COUNT(*) FROM stock_movements WHERE product_id = $1 AND reason = $2", id, ReasonOpeningBalance).Scan(&openings)
+	if err != nil {
+		t.Fatalf("count opening movements: %v", err)
+	}
+	if openings != 1 {
+		t.Fatalf("opening_balance movements = %d, want 1", openings)
+	}
+
+	result, err := repo.ReconcileStock(false)
+	if err != nil {
+		t.Fatalf("reconcile stock: %v", err)
+	}
+	for _, d := range result.Discrepancies {
+		if d.ProductID == id {
+			t.Fatalf("unexpected discrepancy for product %d: recorded %d, expected %d", id, d.RecordedStock, d.ExpectedStock)
+		}
+	}
+
+	if _, err := repo.AdjustStock(id, -1, "rusak"); err != nil {
+		t.Fatalf("adjust stock: %v", err)
+	}
+	_, err = repo.SetOpeningStock([]models.OpeningStockItem{{ProductID: id, Stock: &stock}})
+	var history *StockHistoryError
+	if !errors.As(err, &history) {
+		t.Fatalf("err = %v, want StockHistoryError", err)
+	}
+}
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"database/sql"
+	"kasir-api/models"
+)
+
+// AuditPromoApply dicatat untuk setiap harga produk yang berubah karena promo set diterapkan
+const AuditPromoApply = "promo_apply"
+
+// PromoRepository mengelola operasi database untuk tabel price_snapshots
+type PromoRepository struct {
+	db *sql.DB
+}
+
+// NewPromoRepository membuat instance baru dari PromoRepository
+func NewPromoRepository(db *sql.DB) *PromoRepository {
+	return &PromoRepository{db: db}
+}
+
+// Snapshot menyimpan harga semua produk saat ini sebagai promo set dengan nama tertentu
+// Jika nama sudah ada, isi promo set lama diganti dengan harga terbaru
+func (repo *PromoRepository) Snapshot(name string) (*models.PromoSnapshotResult, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM price_snapshots WHERE name = $1", name)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO price_snapshots (name, product_id, price)
+		SELECT $1, id, price FROM products
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.PromoSnapshotResult{Name: name, ProductsSaved: int(saved)}, nil
+}
+
+// Apply mengubah harga produk sesuai promo set dalam satu transaksi
+// Produk yang sudah tidak ada dilewati dan dilaporkan di SkippedProductIDs
+func (repo *PromoRepository) Apply(name string) (*models.PromoApplyResult, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// LEFT JOIN agar produk yang sudah dihapus tetap terbaca (p.id NULL)
+	rows, err := tx.Query(`
+		SELECT s.product_id, s.price, p.id IS NOT NULL AS product_exists
+		FROM price_snapshots s
+		LEFT JOIN products p ON p.id = s.product_id
+		WHERE s.name = $1
+		ORDER BY s.product_id
+	`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	type snapshotPrice struct {
+		productID int
+		price     int
+	}
+	prices := make([]snapshotPrice, 0)
+	result := &models.PromoApplyResult{Name: name, SkippedProductIDs: make([]int, 0)}
+	found := false
+	for rows.Next() {
+		var sp snapshotPrice
+		var exists bool
+		if err := rows.Scan(&sp.productID, &sp.price, &exists); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		found = true
+		if !exists {
+			result.SkippedProductIDs = append(result.SkippedProductIDs, sp.productID)
+			continue
+		}
+		prices = append(prices, sp)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, &NotFoundError{Resource: "promo"}
+	}
+
+	for _, sp := range prices {
+		res, err := tx.Exec("UPDATE products SET price = $1, updated_at = NOW() WHERE id = $2", sp.price, sp.productID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Produk bisa terhapus di antara SELECT dan UPDATE
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected == 0 {
+			result.SkippedProductIDs = append(result.SkippedProductIDs, sp.productID)
+			continue
+		}
+
+		payload := map[string]interface{}{"promo": name, "price": sp.price}
+		if err := recordAudit(tx, sp.productID, AuditPromoApply, payload); err != nil {
+			return nil, err
+		}
+		result.Applied++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -2,7 +2,12 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"kasir-api/models"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 type ReportRepository struct {
@@ -13,64 +18,878 @@ func NewReportRepository(db *sql.DB) *ReportRepository {
 	return &ReportRepository{db: db}
 }
 
-func (r *ReportRepository) GetTodayReport() (*models.ReportResponse, error) {
+// localTime mengubah kolom TIMESTAMP menjadi waktu lokal di timezone parameter ke-param (contoh Asia/Jakarta)
+// created_at disimpan dari NOW() dalam timezone session database (UTC di Railway),
+// jadi dibaca dulu sebagai waktu timezone session lalu dikonversi ke timezone tujuan
+func localTime(column string, param int) string {
+	return fmt.Sprintf("((%s AT TIME ZONE current_setting('TimeZone')) AT TIME ZONE $%d)", column, param)
+}
+
+// localDate adalah tanggal dari localTime, dipakai untuk filter dan pengelompokan per tanggal di report
+func localDate(column string, param int) string {
+	return "DATE(" + localTime(column, param) + ")"
+}
+
+// GetTodayReport mengambil total hari ini (menurut timezone tz) beserta limit produk terlaris
+func (r *ReportRepository) GetTodayReport(limit int, tz string) (*models.ReportResponse, error) {
 	var report models.ReportResponse
 
 	// Get total revenue dan total transaksi hari ini
 	err := r.db.QueryRow(`
 		SELECT COALESCE(SUM(total_amount), 0), COUNT(*)
 		FROM transactions
-		WHERE DATE(created_at) = CURRENT_DATE
-	`).Scan(&report.TotalRevenue, &report.TotalTransaksi)
+		WHERE `+localDate("created_at", 1)+` = DATE(NOW() AT TIME ZONE $1) AND voided_at IS NULL
+	`, tz).Scan(&report.TotalRevenue, &report.TotalTransaksi)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get produk terlaris hari ini
-	err = r.db.QueryRow(`
+	rows, err := r.db.Query(`
 		SELECT p.name, COALESCE(SUM(td.quantity), 0) as qty_terjual
 		FROM transaction_details td
 		JOIN products p ON p.id = td.product_id
 		JOIN transactions t ON t.id = td.transaction_id
-		WHERE DATE(t.created_at) = CURRENT_DATE
+		WHERE `+localDate("t.created_at", 2)+` = DATE(NOW() AT TIME ZONE $2) AND t.voided_at IS NULL
 		GROUP BY p.id, p.name
 		ORDER BY qty_terjual DESC
-		LIMIT 1
-	`).Scan(&report.ProdukTerlaris.Nama, &report.ProdukTerlaris.QtyTerjual)
-	if err != nil && err != sql.ErrNoRows {
+		LIMIT $1
+	`, limit, tz)
+	if err != nil {
+		return nil, err
+	}
+	report.ProdukTerlaris, err = scanTopProducts(rows)
+	if err != nil {
 		return nil, err
 	}
 
 	return &report, nil
 }
 
-func (r *ReportRepository) GetReportByDateRange(startDate, endDate string) (*models.ReportResponse, error) {
+// GetReportByDateRange mengambil total dalam range (tanggal menurut timezone tz) beserta limit produk terlaris
+func (r *ReportRepository) GetReportByDateRange(startDate, endDate string, limit int, tz string) (*models.ReportResponse, error) {
 	var report models.ReportResponse
 
 	// Get total revenue dan total transaksi dalam range
 	err := r.db.QueryRow(`
 		SELECT COALESCE(SUM(total_amount), 0), COUNT(*)
 		FROM transactions
-		WHERE DATE(created_at) >= $1 AND DATE(created_at) <= $2
-	`, startDate, endDate).Scan(&report.TotalRevenue, &report.TotalTransaksi)
+		WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+	`, startDate, endDate, tz).Scan(&report.TotalRevenue, &report.TotalTransaksi)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get produk terlaris dalam range
+	report.ProdukTerlaris, err = r.GetTopProducts(startDate, endDate, limit, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetTicketDistribution mengelompokkan transaksi dalam range berdasarkan total_amount
+// boundaries harus terurut naik, contoh [10000, 50000, 100000] menghasilkan 4 bucket:
+// < 10000, 10000 - 49999, 50000 - 99999, >= 100000
+func (r *ReportRepository) GetTicketDistribution(startDate, endDate string, boundaries []int, tz string) ([]models.TicketBucket, error) {
+	// width_bucket dengan array threshold mengembalikan index bucket 0..len(boundaries)
+	rows, err := r.db.Query(`
+		SELECT width_bucket(total_amount, $3::int[]) AS bucket, COUNT(*), COALESCE(SUM(total_amount), 0)
+		FROM transactions
+		WHERE `+localDate("created_at", 4)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+		GROUP BY bucket
+	`, startDate, endDate, pq.Array(boundaries), tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Siapkan semua bucket terlebih dahulu agar bucket kosong tetap muncul dengan nilai 0
+	buckets := make([]models.TicketBucket, len(boundaries)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].MinAmount = boundaries[i-1]
+		}
+		if i < len(boundaries) {
+			max := boundaries[i] - 1
+			buckets[i].MaxAmount = &max
+		}
+
+		switch {
+		case i == 0:
+			buckets[i].Label = fmt.Sprintf("< %d", boundaries[0])
+		case i == len(boundaries):
+			buckets[i].Label = fmt.Sprintf(">= %d", boundaries[i-1])
+		default:
+			buckets[i].Label = fmt.Sprintf("%d - %d", boundaries[i-1], boundaries[i]-1)
+		}
+	}
+
+	for rows.Next() {
+		var index, count, revenue int
+		if err := rows.Scan(&index, &count, &revenue); err != nil {
+			return nil, err
+		}
+		buckets[index].Count = count
+		buckets[index].Revenue = revenue
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
+
+// GetThroughput menghitung puncak transaksi per jam dalam range berdasarkan created_at
+func (r *ReportRepository) GetThroughput(startDate, endDate, tz string) (*models.ThroughputReport, error) {
+	var report models.ThroughputReport
+
+	// Total transaksi dan jumlah jam yang memiliki minimal 1 transaksi
+	err := r.db.QueryRow(`
+		SELECT COUNT(*), COUNT(DISTINCT date_trunc('hour', `+localTime("created_at", 3)+`))
+		FROM transactions
+		WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+	`, startDate, endDate, tz).Scan(&report.TotalTransaksi, &report.JamAktif)
+	if err != nil {
+		return nil, err
+	}
+	if report.JamAktif > 0 {
+		report.RataRataPerJamAktif = float64(report.TotalTransaksi) / float64(report.JamAktif)
+	}
+
+	// Jam tersibuk (tanggal + jam) dengan transaksi terbanyak
+	var peakHour time.Time
 	err = r.db.QueryRow(`
+		SELECT date_trunc('hour', `+localTime("created_at", 3)+`) AS jam, COUNT(*) AS total
+		FROM transactions
+		WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+		GROUP BY jam
+		ORDER BY total DESC, jam ASC
+		LIMIT 1
+	`, startDate, endDate, tz).Scan(&peakHour, &report.PeakHourTransaksi)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		report.PeakHour = &peakHour
+	}
+
+	// Jam dalam sehari (0-23) yang rata-rata paling ramai sepanjang range
+	var busiestHour int
+	err = r.db.QueryRow(`
+		SELECT EXTRACT(HOUR FROM `+localTime("created_at", 3)+`)::int AS jam,
+			COUNT(*)::float / COUNT(DISTINCT `+localDate("created_at", 3)+`) AS rata_rata
+		FROM transactions
+		WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+		GROUP BY jam
+		ORDER BY rata_rata DESC, jam ASC
+		LIMIT 1
+	`, startDate, endDate, tz).Scan(&busiestHour, &report.BusiestHourAvg)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		report.BusiestHourOfDay = &busiestHour
+	}
+
+	return &report, nil
+}
+
+// GetBasketSize menghitung rata-rata jumlah item unik dan total quantity per transaksi untuk setiap hari dalam range
+// Hari tanpa transaksi tetap muncul dengan nilai 0 (generate_series)
+func (r *ReportRepository) GetBasketSize(startDate, endDate, tz string) ([]models.BasketSize, error) {
+	rows, err := r.db.Query(`
+		WITH per_transaksi AS (
+			SELECT t.id, `+localDate("t.created_at", 3)+` AS tanggal,
+				COUNT(DISTINCT td.product_id) AS distinct_items,
+				COALESCE(SUM(td.quantity), 0) AS quantity
+			FROM transactions t
+			LEFT JOIN transaction_details td ON td.transaction_id = t.id
+			WHERE `+localDate("t.created_at", 3)+` BETWEEN $1 AND $2 AND t.voided_at IS NULL
+			GROUP BY t.id, tanggal
+		)
+		SELECT to_char(d, 'YYYY-MM-DD'), COUNT(pt.id),
+			COALESCE(AVG(pt.distinct_items), 0), COALESCE(AVG(pt.quantity), 0)
+		FROM generate_series($1::date, $2::date, interval '1 day') AS d
+		LEFT JOIN per_transaksi pt ON pt.tanggal = d::date
+		GROUP BY d
+		ORDER BY d
+	`, startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.BasketSize, 0)
+	for rows.Next() {
+		var b models.BasketSize
+		if err := rows.Scan(&b.Tanggal, &b.TotalTransaksi, &b.AvgDistinctItems, &b.AvgQuantity); err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetDailyBreakdown mengambil revenue dan jumlah transaksi per tanggal (menurut timezone tz) dalam range
+func (r *ReportRepository) GetDailyBreakdown(startDate, endDate, tz string) ([]models.DailyReport, error) {
+	rows, err := r.db.Query(`
+		SELECT to_char(`+localDate("created_at", 3)+`, 'YYYY-MM-DD') AS tanggal, COALESCE(SUM(total_amount), 0), COUNT(*)
+		FROM transactions
+		WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+		GROUP BY tanggal
+		ORDER BY tanggal
+	`, startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make([]models.DailyReport, 0)
+	for rows.Next() {
+		var d models.DailyReport
+		if err := rows.Scan(&d.Tanggal, &d.TotalRevenue, &d.TotalTransaksi); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// GetTopProducts mengambil N produk dengan quantity terjual terbanyak dalam range (tanggal menurut timezone tz)
+func (r *ReportRepository) GetTopProducts(startDate, endDate string, limit int, tz string) ([]models.ProdukTerlaris, error) {
+	rows, err := r.db.Query(`
 		SELECT p.name, COALESCE(SUM(td.quantity), 0) as qty_terjual
 		FROM transaction_details td
 		JOIN products p ON p.id = td.product_id
 		JOIN transactions t ON t.id = td.transaction_id
-		WHERE DATE(t.created_at) >= $1 AND DATE(t.created_at) <= $2
+		WHERE `+localDate("t.created_at", 4)+` BETWEEN $1 AND $2 AND t.voided_at IS NULL
 		GROUP BY p.id, p.name
 		ORDER BY qty_terjual DESC
-		LIMIT 1
-	`, startDate, endDate).Scan(&report.ProdukTerlaris.Nama, &report.ProdukTerlaris.QtyTerjual)
-	if err != nil && err != sql.ErrNoRows {
+		LIMIT $3
+	`, startDate, endDate, limit, tz)
+	if err != nil {
+		return nil, err
+	}
+	return scanTopProducts(rows)
+}
+
+// scanTopProducts membaca hasil query (nama, qty_terjual) lalu menutup rows
+func scanTopProducts(rows *sql.Rows) ([]models.ProdukTerlaris, error) {
+	defer rows.Close()
+
+	products := make([]models.ProdukTerlaris, 0)
+	for rows.Next() {
+		var p models.ProdukTerlaris
+		if err := rows.Scan(&p.Nama, &p.QtyTerjual); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// GetItemsSold menghitung total quantity barang terjual dalam range
+func (r *ReportRepository) GetItemsSold(startDate, endDate, tz string) (int, error) {
+	var total int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(td.quantity), 0)
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		WHERE `+localDate("t.created_at", 3)+` BETWEEN $1 AND $2 AND t.voided_at IS NULL
+	`, startDate, endDate, tz).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// GetReorderByCategory mengambil produk dengan stok <= reorder point efektifnya, dikelompokkan per kategori
+// Reorder point efektif = min_stock produk, jika NULL pakai min_stock kategori
+func (r *ReportRepository) GetReorderByCategory() ([]models.ReorderGroup, error) {
+	rows, err := r.db.Query(`
+		SELECT COALESCE(c.id, 0), COALESCE(c.name, 'Uncategorized'), p.id, p.name, p.stock,
+			` + effectiveMinStock + ` AS reorder_point
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.stock <= ` + effectiveMinStock + `
+		ORDER BY c.name ASC NULLS LAST, c.id, p.stock ASC, p.name ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]models.ReorderGroup, 0)
+	for rows.Next() {
+		var categoryID int
+		var categoryName string
+		var item models.ReorderItem
+		if err := rows.Scan(&categoryID, &categoryName, &item.ProductID, &item.Nama, &item.Stock, &item.ReorderPoint); err != nil {
+			return nil, err
+		}
+
+		// Baris sudah terurut per kategori, cukup buat grup baru saat kategori berganti
+		if len(groups) == 0 || groups[len(groups)-1].CategoryID != categoryID {
+			groups = append(groups, models.ReorderGroup{
+				CategoryID:   categoryID,
+				CategoryName: categoryName,
+				Products:     make([]models.ReorderItem, 0),
+			})
+		}
+		last := &groups[len(groups)-1]
+		last.Products = append(last.Products, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// GetTransactionInterval menghitung rata-rata jarak waktu antar transaksi berurutan dalam range
+// Jarak dihitung per hari (PARTITION BY tanggal) agar jam tutup toko tidak ikut terhitung
+func (r *ReportRepository) GetTransactionInterval(startDate, endDate, tz string) (*models.TransactionInterval, error) {
+	var report models.TransactionInterval
+	err := r.db.QueryRow(`
+		WITH intervals AS (
+			SELECT EXTRACT(EPOCH FROM created_at - LAG(created_at) OVER (
+				PARTITION BY `+localDate("created_at", 3)+` ORDER BY created_at
+			)) AS gap
+			FROM transactions
+			WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+		)
+		SELECT COUNT(*), AVG(gap)::float, MIN(gap)::float, MAX(gap)::float
+		FROM intervals
+	`, startDate, endDate, tz).Scan(&report.TotalTransaksi, &report.AvgIntervalSeconds, &report.MinIntervalSeconds, &report.MaxIntervalSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetHourlyPattern menghitung rata-rata revenue dan jumlah transaksi per jam (0-23) dalam range
+// Rata-rata dibagi jumlah hari di range (termasuk hari tanpa transaksi), selalu mengembalikan 24 bucket
+func (r *ReportRepository) GetHourlyPattern(startDate, endDate, tz string) ([]models.HourlyPattern, error) {
+	rows, err := r.db.Query(`
+		WITH per_jam AS (
+			SELECT EXTRACT(HOUR FROM `+localTime("created_at", 3)+`)::int AS jam, SUM(total_amount) AS revenue, COUNT(*) AS transaksi
+			FROM transactions
+			WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+			GROUP BY jam
+		)
+		SELECT h.jam,
+			COALESCE(pj.revenue, 0)::float / ($2::date - $1::date + 1),
+			COALESCE(pj.transaksi, 0)::float / ($2::date - $1::date + 1),
+			COALESCE(pj.revenue, 0), COALESCE(pj.transaksi, 0)
+		FROM generate_series(0, 23) AS h(jam)
+		LEFT JOIN per_jam pj ON pj.jam = h.jam
+		ORDER BY h.jam
+	`, startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pattern := make([]models.HourlyPattern, 0, 24)
+	for rows.Next() {
+		var h models.HourlyPattern
+		if err := rows.Scan(&h.Jam, &h.AvgRevenue, &h.AvgTransaksi, &h.TotalRevenue, &h.TotalTransaksi); err != nil {
+			return nil, err
+		}
+		pattern = append(pattern, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pattern, nil
+}
+
+// GetReportForDates mengambil revenue dan jumlah transaksi untuk setiap tanggal di daftar (tidak harus berurutan)
+// Tanggal tanpa transaksi tetap dikembalikan dengan nilai 0
+func (r *ReportRepository) GetReportForDates(dates []string, tz string) ([]models.DailyReport, error) {
+	rows, err := r.db.Query(`
+		WITH per_tanggal AS (
+			SELECT `+localDate("created_at", 2)+` AS tanggal, SUM(total_amount) AS revenue, COUNT(*) AS transaksi
+			FROM transactions
+			WHERE `+localDate("created_at", 2)+` = ANY($1::date[]) AND voided_at IS NULL
+			GROUP BY tanggal
+		)
+		SELECT to_char(d.tanggal, 'YYYY-MM-DD'), COALESCE(pt.revenue, 0), COALESCE(pt.transaksi, 0)
+		FROM unnest($1::date[]) AS d(tanggal)
+		LEFT JOIN per_tanggal pt ON pt.tanggal = d.tanggal
+		ORDER BY d.tanggal
+	`, pq.Array(dates), tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := make([]models.DailyReport, 0, len(dates))
+	for rows.Next() {
+		var d models.DailyReport
+		if err := rows.Scan(&d.Tanggal, &d.TotalRevenue, &d.TotalTransaksi); err != nil {
+			return nil, err
+		}
+		days = append(days, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// GetProductRevenue mengambil total revenue per produk dalam range, terbesar di awal
+// Hanya produk yang terjual dalam range yang ikut
+func (r *ReportRepository) GetProductRevenue(startDate, endDate, tz string) ([]models.ProductRevenue, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, SUM(td.subtotal) AS revenue
+		FROM transaction_details td
+		JOIN products p ON p.id = td.product_id
+		JOIN transactions t ON t.id = td.transaction_id
+		WHERE `+localDate("t.created_at", 3)+` BETWEEN $1 AND $2 AND t.voided_at IS NULL
+		GROUP BY p.id, p.name
+		ORDER BY revenue DESC, p.id ASC
+	`, startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.ProductRevenue, 0)
+	for rows.Next() {
+		var p models.ProductRevenue
+		if err := rows.Scan(&p.ProductID, &p.Nama, &p.Revenue); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// GetAnomalies mengambil transaksi yang totalnya melebihi multiplier x rata-rata total transaksi dalam range
+// Rata-rata dihitung dari range itu sendiri
+func (r *ReportRepository) GetAnomalies(startDate, endDate string, multiplier float64, tz string) (*models.AnomalyReport, error) {
+	report := models.AnomalyReport{
+		Multiplier:   multiplier,
+		Transactions: make([]models.AnomalyTransaction, 0),
+	}
+
+	err := r.db.QueryRow(`
+		SELECT COALESCE(AVG(total_amount), 0)::float
+		FROM transactions
+		WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+	`, startDate, endDate, tz).Scan(&report.AverageTicket)
+	if err != nil {
+		return nil, err
+	}
+	report.Threshold = report.AverageTicket * multiplier
+
+	rows, err := r.db.Query(`
+		SELECT id, total_amount, created_at
+		FROM transactions
+		WHERE `+localDate("created_at", 4)+` BETWEEN $1 AND $2 AND total_amount > $3 AND voided_at IS NULL
+		ORDER BY total_amount DESC, id ASC
+	`, startDate, endDate, report.Threshold, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.AnomalyTransaction
+		if err := rows.Scan(&t.ID, &t.TotalAmount, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		report.Transactions = append(report.Transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetCategoryAttachRate menghitung untuk setiap kategori berapa transaksi yang berisi kategori tersebut
+// dan rata-rata qty-nya per transaksi. Produk tanpa kategori dikelompokkan sebagai Uncategorized (id 0)
+func (r *ReportRepository) GetCategoryAttachRate(startDate, endDate, tz string) ([]models.CategoryAttachRate, error) {
+	rows, err := r.db.Query(`
+		WITH transaksi AS (
+			SELECT id
+			FROM transactions
+			WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+		),
+		per_kategori AS (
+			SELECT COALESCE(c.id, 0) AS category_id, COALESCE(c.name, 'Uncategorized') AS category_name,
+				td.transaction_id, SUM(td.quantity) AS quantity
+			FROM transaction_details td
+			JOIN transaksi t ON t.id = td.transaction_id
+			LEFT JOIN products p ON p.id = td.product_id
+			LEFT JOIN categories c ON c.id = p.category_id
+			GROUP BY 1, 2, td.transaction_id
+		)
+		SELECT category_id, category_name, COUNT(*),
+			COUNT(*)::float / NULLIF((SELECT COUNT(*) FROM transaksi), 0),
+			AVG(quantity)::float
+		FROM per_kategori
+		GROUP BY category_id, category_name
+		ORDER BY COUNT(*) DESC, category_name ASC
+	`, startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.CategoryAttachRate, 0)
+	for rows.Next() {
+		var a models.CategoryAttachRate
+		if err := rows.Scan(&a.CategoryID, &a.CategoryName, &a.TotalTransaksi, &a.AttachRate, &a.AvgQuantity); err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// SaveDailySnapshot menyimpan ringkasan tutup kasir, snapshot tanggal yang sama akan ditimpa
+func (r *ReportRepository) SaveDailySnapshot(closing *models.DailyClosing) (*models.DailySnapshot, error) {
+	topProducts, err := json.Marshal(closing.TopProducts)
+	if err != nil {
+		return nil, err
+	}
+	paymentMethods, err := json.Marshal(closing.PaymentMethods)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := models.DailySnapshot{DailyClosing: *closing}
+	err = r.db.QueryRow(`
+		INSERT INTO daily_snapshots (tanggal, total_revenue, total_transaksi, item_terjual, top_products, payment_methods)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tanggal) DO UPDATE SET
+			total_revenue = EXCLUDED.total_revenue,
+			total_transaksi = EXCLUDED.total_transaksi,
+			item_terjual = EXCLUDED.item_terjual,
+			top_products = EXCLUDED.top_products,
+			payment_methods = EXCLUDED.payment_methods,
+			snapshot_at = NOW()
+		RETURNING snapshot_at
+	`, closing.Tanggal, closing.TotalRevenue, closing.TotalTransaksi, closing.ItemTerjual, topProducts, paymentMethods).Scan(&snapshot.SnapshotAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// GetDailySnapshot mengambil snapshot yang tersimpan untuk satu tanggal
+func (r *ReportRepository) GetDailySnapshot(date string) (*models.DailySnapshot, error) {
+	var snapshot models.DailySnapshot
+	var topProducts, paymentMethods []byte
+	err := r.db.QueryRow(`
+		SELECT to_char(tanggal, 'YYYY-MM-DD'), total_revenue, total_transaksi, item_terjual, top_products, payment_methods, snapshot_at
+		FROM daily_snapshots
+		WHERE tanggal = $1
+	`, date).Scan(&snapshot.Tanggal, &snapshot.TotalRevenue, &snapshot.TotalTransaksi, &snapshot.ItemTerjual,
+		&topProducts, &paymentMethods, &snapshot.SnapshotAt)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "snapshot"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(topProducts, &snapshot.TopProducts); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(paymentMethods, &snapshot.PaymentMethods); err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// GetPaymentMethodTotals mengambil revenue dan jumlah transaksi per metode pembayaran dalam range (tanggal menurut timezone tz)
+func (r *ReportRepository) GetPaymentMethodTotals(startDate, endDate, tz string) ([]models.PaymentMethodTotal, error) {
+	rows, err := r.db.Query(`
+		SELECT payment_method, COALESCE(SUM(total_amount), 0), COUNT(*)
+		FROM transactions
+		WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+		GROUP BY payment_method
+		ORDER BY payment_method
+	`, startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make([]models.PaymentMethodTotal, 0)
+	for rows.Next() {
+		var m models.PaymentMethodTotal
+		if err := rows.Scan(&m.PaymentMethod, &m.TotalRevenue, &m.TotalTransaksi); err != nil {
+			return nil, err
+		}
+		totals = append(totals, m)
+	}
+	return totals, rows.Err()
+}
+
+// GetInventorySummary menghitung nilai inventori berdasarkan harga jual serta jumlah produk low stock dan habis
+// Definisi low stock sama dengan StockStatus: stok > 0 dan <= min_stock efektif (produk atau kategori)
+func (r *ReportRepository) GetInventorySummary() (*models.InventorySummary, error) {
+	var summary models.InventorySummary
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(p.price::bigint * GREATEST(p.stock, 0)), 0),
+			COUNT(*) FILTER (WHERE p.stock > 0 AND p.stock <= `+effectiveMinStock+`),
+			COUNT(*) FILTER (WHERE p.stock <= 0)
+		FROM products p
+		LEFT JOIN categories c ON c.id = p.category_id
+	`).Scan(&summary.InventoryValue, &summary.LowStockCount, &summary.OutOfStockCount)
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// GetCustomerMix membagi transaksi dalam range menjadi pelanggan baru, pelanggan lama, dan anonim
+// Pelanggan baru = pembelian pertamanya (sepanjang waktu) terjadi di dalam range
+// Ketiga segmen selalu dikembalikan walaupun kosong
+func (r *ReportRepository) GetCustomerMix(startDate, endDate, tz string) ([]models.CustomerMixBucket, error) {
+	rows, err := r.db.Query(`
+		WITH first_purchase AS (
+			SELECT customer_id, MIN(`+localDate("created_at", 3)+`) AS first_date
+			FROM transactions
+			WHERE customer_id IS NOT NULL AND voided_at IS NULL
+			GROUP BY customer_id
+		),
+		classified AS (
+			SELECT t.customer_id, t.total_amount,
+				CASE
+					WHEN t.customer_id IS NULL THEN 'anonymous'
+					WHEN fp.first_date >= $1::date THEN 'new'
+					ELSE 'returning'
+				END AS segment
+			FROM transactions t
+			LEFT JOIN first_purchase fp ON fp.customer_id = t.customer_id
+			WHERE `+localDate("t.created_at", 3)+` BETWEEN $1 AND $2 AND t.voided_at IS NULL
+		)
+		SELECT s.segment, COUNT(DISTINCT c.customer_id), COUNT(c.segment), COALESCE(SUM(c.total_amount), 0)
+		FROM unnest(ARRAY['new', 'returning', 'anonymous']) WITH ORDINALITY AS s(segment, urutan)
+		LEFT JOIN classified c ON c.segment = s.segment
+		GROUP BY s.segment, s.urutan
+		ORDER BY s.urutan
+	`, startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.CustomerMixBucket, 0, 3)
+	for rows.Next() {
+		var b models.CustomerMixBucket
+		if err := rows.Scan(&b.Segment, &b.TotalCustomers, &b.TotalTransaksi, &b.TotalRevenue); err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetStockCover mengambil stok dan total terjual dalam N hari terakhir untuk semua produk
+// Perhitungan days of cover dilakukan di service
+func (r *ReportRepository) GetStockCover(days int) ([]models.StockCover, error) {
+	rows, err := r.db.Query(`
+		WITH sold AS (
+			SELECT td.product_id, SUM(td.quantity) AS total
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE t.voided_at IS NULL AND t.created_at >= NOW() - make_interval(days => $1)
+			GROUP BY td.product_id
+		)
+		SELECT p.id, p.name, p.stock, COALESCE(s.total, 0)
+		FROM products p
+		LEFT JOIN sold s ON s.product_id = p.id
+		ORDER BY p.name ASC, p.id ASC
+	`, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]models.StockCover, 0)
+	for rows.Next() {
+		var c models.StockCover
+		if err := rows.Scan(&c.ProductID, &c.Nama, &c.Stock, &c.TotalSold); err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetCategoryTrend mengambil revenue dan qty sebuah kategori untuk N periode terakhir, periode kosong bernilai 0
+func (r *ReportRepository) GetCategoryTrend(categoryID int, period string, count int, tz string) ([]models.TrendPoint, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1)", categoryID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &NotFoundError{Resource: "category", ID: categoryID}
+	}
+
+	return querySalesTrend(r.db, "p.category_id", categoryID, period, count, tz)
+}
+
+// netLineRevenue adalah subtotal item setelah diskon transaksi dan diskon loyalty dibagi proporsional ke item
+// Pajak tidak termasuk. Dipakai dengan alias td (transaction_details) dan t (transactions), hasilnya NUMERIC
+const netLineRevenue = "COALESCE(td.subtotal::numeric * (t.subtotal - t.discount - t.loyalty_discount) / NULLIF(t.subtotal, 0), 0)"
+
+// GetProfitReport menghitung revenue, modal, dan laba dari item terjual dalam range
+// Revenue memakai netLineRevenue (sudah dikurangi diskon item, diskon transaksi, dan diskon loyalty, tanpa pajak),
+// modal memakai cost_price produk saat ini
+// Produk dengan cost_price 0 atau yang sudah dihapus dipisahkan karena modalnya tidak diketahui
+func (r *ReportRepository) GetProfitReport(startDate, endDate, tz string) (*models.ProfitReport, error) {
+	report := models.ProfitReport{StartDate: startDate, EndDate: endDate}
+	err := r.db.QueryRow(`
+		SELECT
+			ROUND(COALESCE(SUM(`+netLineRevenue+`), 0))::int,
+			COALESCE(SUM(td.quantity * p.cost_price) FILTER (WHERE p.cost_price > 0), 0),
+			ROUND(COALESCE(SUM(`+netLineRevenue+`) FILTER (WHERE COALESCE(p.cost_price, 0) = 0), 0))::int,
+			COUNT(DISTINCT td.product_id) FILTER (WHERE COALESCE(p.cost_price, 0) = 0)
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		LEFT JOIN products p ON p.id = td.product_id
+		WHERE `+localDate("t.created_at", 3)+` BETWEEN $1 AND $2 AND t.voided_at IS NULL
+	`, startDate, endDate, tz).Scan(&report.TotalRevenue, &report.TotalCost,
+		&report.RevenueWithoutCost, &report.ProductsWithoutCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetRevenueByCategory menjumlahkan revenue bersih item terjual (netLineRevenue) per kategori, terurut dari revenue terbesar
+// Memakai LEFT JOIN agar item tanpa kategori tetap terhitung di Uncategorized
+// Total revenue dan pajak transaksi dalam range ikut dikembalikan agar service bisa merekonsiliasi dengan /api/report
+func (r *ReportRepository) GetRevenueByCategory(startDate, endDate, tz string) (*models.CategoryRevenueReport, error) {
+	report := models.CategoryRevenueReport{StartDate: startDate, EndDate: endDate}
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(total_amount), 0), COALESCE(SUM(tax), 0)
+		FROM transactions
+		WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+	`, startDate, endDate, tz).Scan(&report.TotalRevenue, &report.Tax)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT COALESCE(c.id, 0), COALESCE(c.name, 'Uncategorized'),
+			ROUND(COALESCE(SUM(`+netLineRevenue+`), 0))::int, COUNT(DISTINCT td.transaction_id)
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		LEFT JOIN products p ON p.id = td.product_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE `+localDate("t.created_at", 3)+` BETWEEN $1 AND $2 AND t.voided_at IS NULL
+		GROUP BY 1, 2
+		ORDER BY 3 DESC, 2 ASC
+	`, startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report.Categories = make([]models.CategoryRevenue, 0)
+	for rows.Next() {
+		var c models.CategoryRevenue
+		if err := rows.Scan(&c.CategoryID, &c.CategoryName, &c.Revenue, &c.TotalTransaksi); err != nil {
+			return nil, err
+		}
+		report.Categories = append(report.Categories, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetDiscountReport menjumlahkan diskon item, diskon transaksi, dan diskon loyalty dalam range (tanggal menurut timezone tz)
+func (r *ReportRepository) GetDiscountReport(startDate, endDate, tz string) (*models.DiscountReport, error) {
+	report := models.DiscountReport{StartDate: startDate, EndDate: endDate}
+	err := r.db.QueryRow(`
+		WITH tr AS (
+			SELECT id, discount, loyalty_discount
+			FROM transactions
+			WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+		),
+		items AS (
+			SELECT td.transaction_id, SUM(td.discount) AS discount
+			FROM transaction_details td
+			JOIN tr ON tr.id = td.transaction_id
+			GROUP BY td.transaction_id
+		)
+		SELECT COALESCE(SUM(i.discount), 0), COALESCE(SUM(tr.discount), 0), COALESCE(SUM(tr.loyalty_discount), 0),
+			COUNT(*) FILTER (WHERE COALESCE(i.discount, 0) > 0 OR tr.discount > 0),
+			COUNT(*)
+		FROM tr
+		LEFT JOIN items i ON i.transaction_id = tr.id
+	`, startDate, endDate, tz).Scan(&report.ItemDiscount, &report.TransactionDiscount, &report.LoyaltyDiscount,
+		&report.DiscountedTransactions, &report.TotalTransaksi)
+	if err != nil {
+		return nil, err
+	}
+
+	report.TotalDiscount = report.ItemDiscount + report.TransactionDiscount
+	return &report, nil
+}
+
+// GetTaxReport menjumlahkan pajak serta subtotal kena pajak dan bebas pajak dalam range (tanggal menurut timezone tz)
+func (r *ReportRepository) GetTaxReport(startDate, endDate, tz string) (*models.TaxReport, error) {
+	report := models.TaxReport{StartDate: startDate, EndDate: endDate}
+	var subtotal int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(tax), 0), COALESCE(SUM(taxable_subtotal), 0), COALESCE(SUM(subtotal), 0), COUNT(*)
+		FROM transactions
+		WHERE `+localDate("created_at", 3)+` BETWEEN $1 AND $2 AND voided_at IS NULL
+	`, startDate, endDate, tz).Scan(&report.TaxCollected, &report.TaxableSubtotal, &subtotal, &report.TotalTransaksi)
+	if err != nil {
 		return nil, err
 	}
 
+	report.ExemptSubtotal = subtotal - report.TaxableSubtotal
 	return &report, nil
 }
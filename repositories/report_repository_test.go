@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// insertTestTransaction menyimpan transaksi pada waktu tertentu (dengan offset timezone) dan menghapusnya setelah test
+// created_at dikonversi ke timezone session database, sama seperti nilai NOW() saat checkout
+func insertTestTransaction(t *testing.T, db *sql.DB, total int, at string) {
+	t.Helper()
+
+	var id int
+	err := db.QueryRow(`
+		INSERT INTO transactions (total_amount, created_at)
+		VALUES ($1, $2::timestamptz AT TIME ZONE current_setting('TimeZone'))
+		RETURNING id
+	`, total, at).Scan(&id)
+	if err != nil {
+		t.Fatalf("insert transaction: %v", err)
+	}
+	t.Cleanup(func() { db.Exec("DELETE FROM transactions WHERE id = $1", id) })
+}
+
+// Penjualan jam 23:30 WIB harus masuk ke tanggal lokal yang sama,
+// dan penjualan jam 06:30 WIB (23:30 UTC hari sebelumnya) masuk ke tanggal lokal berikutnya
+func TestDailyBreakdownUsesLocalDate(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewReportRepository(db)
+
+	// Tanggal lama agar tidak bercampur dengan data lain di database test
+	insertTestTransaction(t, db, 12345, "1999-03-10 23:30:00+07")
+	insertTestTransaction(t, db, 500, "1999-03-11 06:30:00+07")
+
+	days, err := repo.GetDailyBreakdown("1999-03-10", "1999-03-11", "Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("daily breakdown: %v", err)
+	}
+
+	want := map[string]int{"1999-03-10": 12345, "1999-03-11": 500}
+	if len(days) != len(want) {
+		t.Fatalf("got %d days, want %d: %+v", len(days), len(want), days)
+	}
+	for _, d := range days {
+		if d.TotalRevenue != want[d.Tanggal] || d.TotalTransaksi != 1 {
+			t.Errorf("%s: revenue %d transaksi %d, want revenue %d transaksi 1", d.Tanggal, d.TotalRevenue, d.TotalTransaksi, want[d.Tanggal])
+		}
+	}
+
+	report, err := repo.GetReportByDateRange("1999-03-10", "1999-03-10", 5, "Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("report by date range: %v", err)
+	}
+	if report.TotalRevenue != 12345 {
+		t.Errorf("range report revenue = %d, want 12345", report.TotalRevenue)
+	}
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/database"
+	"kasir-api/models"
+	"os"
+	"testing"
+	"time"
+)
+
+// openTestDB membuka database dari TEST_DATABASE_URL dan menjalankan migrations
+// Test di-skip jika TEST_DATABASE_URL kosong; database harus sudah punya tabel dasar
+// (products, categories, transactions, transaction_details) dan jangan pakai database production
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping test database: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+// createTestProduct membuat produk dengan nama unik lewat ProductRepository dan menghapusnya setelah test selesai
+func createTestProduct(t *testing.T, db *sql.DB, stock int) int {
+	t.Helper()
+
+	product := models.Product{
+		Name:  fmt.Sprintf("test-%s-%d", t.Name(), time.Now().UnixNano()),
+		Price: 1000,
+		Stock: stock,
+	}
+	if err := NewProductRepository(db).Create(&product); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	t.Cleanup(func() { deleteTestProduct(db, product.ID) })
+	return product.ID
+}
+
+// deleteTestProduct menghapus produk beserta transaksi test yang memakainya
+func deleteTestProduct(db *sql.DB, productID int) {
+	db.Exec(`
+		WITH details AS (
+			DELETE FROM transaction_details WHERE product_id = $1 RETURNING transaction_id
+		)
+		DELETE FROM transactions WHERE id IN (SELECT transaction_id FROM details)
+	`, productID)
+	db.Exec("DELETE FROM products WHERE id = $1", productID)
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"errors"
+	"kasir-api/models"
+	"sync"
+	"testing"
+)
+
+// Dua checkout bersamaan untuk unit terakhir: tepat satu berhasil, yang lain mendapat InsufficientStockError
+func TestCreateTransactionConcurrentLastUnit(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewTransactionRepository(db)
+
+	productID := createTestProduct(t, db, 1)
+
+	const checkouts = 2
+	errs := make([]error, checkouts)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < checkouts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = repo.CreateTransaction(&models.CheckoutRequest{
+				Items:         []models.CheckoutItem{{ProductID: productID, Quantity: 1}},
+				PaymentMethod: models.PaymentCash,
+			}, models.LoyaltyConfig{SpendPerPoint: 10000, PointValue: 100}, 100)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		var insufficient *InsufficientStockError
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.As(err, &insufficient):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d checkouts succeeded, want exactly 1", succeeded)
+	}
+
+	product, err := NewProductRepository(db).GetByID(productID)
+	if err != nil {
+		t.Fatalf("get product: %v", err)
+	}
+	if product.Stock != 0 {
+		t.Fatalf("stock = %d, want 0", product.Stock)
+	}
+}
+
+// Filter tanggal riwayat transaksi memakai tanggal lokal yang sama dengan report harian
+func TestGetAllFiltersByLocalDate(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewTransactionRepository(db)
+
+	insertTestTransaction(t, db, 12345, "1999-03-10 23:30:00+07")
+	insertTestTransaction(t, db, 500, "1999-03-11 06:30:00+07")
+
+	filter := models.TransactionFilter{StartDate: "1999-03-10", EndDate: "1999-03-10"}
+	transactions, total, err := repo.GetAll(filter, "Asia/Jakarta", 10, 0)
+	if err != nil {
+		t.Fatalf("get all: %v", err)
+	}
+	if total != 1 || len(transactions) != 1 || transactions[0].TotalAmount != 12345 {
+		t.Fatalf("got total %d, transactions %+v; want only the 23:30 WIB sale", total, transactions)
+	}
+}
+
+func TestExceedsDiscountLimit(t *testing.T) {
+	cases := []struct {
+		name       string
+		discount   int
+		base       int
+		maxPercent float64
+		want       bool
+	}{
+		{"no discount", 0, 10000, 0, false},
+		{"within limit", 1000, 10000, 10, false},
+		{"exactly at limit", 1500, 10000, 15, false},
+		{"over limit", 1501, 10000, 15, true},
+		{"discounts disabled by zero percent", 1, 10000, 0, true},
+		{"full discount allowed at 100 percent", 10000, 10000, 100, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exceedsDiscountLimit(tc.discount, tc.base, tc.maxPercent); got != tc.want {
+				t.Fatalf("exceedsDiscountLimit(%d, %d, %g) = %v, want %v", tc.discount, tc.base, tc.maxPercent, got, tc.want)
+			}
+		})
+	}
+}
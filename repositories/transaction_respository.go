@@ -4,6 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"kasir-api/models"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 type TransactionRepository struct {
@@ -15,7 +19,10 @@ func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
+// CreateTransaction menyimpan checkout dalam satu transaksi database (stok, poin loyalty, detail item)
+// maxDiscountPercent membatasi diskon item (terhadap harga * qty) dan diskon transaksi (terhadap subtotal),
+// diskon di atas batas ditolak dengan DiscountLimitError
+func (repo *TransactionRepository) CreateTransaction(req *models.CheckoutRequest, loyalty models.LoyaltyConfig, maxDiscountPercent float64) (*models.Transaction, error) {
 	var (
 		res *models.Transaction
 	)
@@ -26,28 +33,66 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 	}
 	defer tx.Rollback() // Jika ada error di tengah-tengah, maka rollback.
 
+	//kunci semua produk di keranjang sekaligus dengan urutan id yang sama untuk setiap checkout
+	//agar dua checkout dengan urutan item berbeda tidak saling menunggu (deadlock)
+	productIDs := make([]int, 0, len(req.Items))
+	for _, item := range req.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	_, err = tx.Exec("SELECT id FROM products WHERE id = ANY($1) ORDER BY id FOR UPDATE", pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+
 	//inisialisasi sub total -> jumlah total keseluruhan transaksi
 	totalAmount := 0
+	//bagian subtotal dari produk yang kena pajak
+	taxableAmount := 0
 	//inisialisasi modelling detail transaksi -> untuk insert ke db
 	details := make([]models.TransactionDetails, 0)
 	//loop setiap item
-	for _, item := range items {
+	for _, item := range req.Items {
 		var productName string
 		var productID, price, stock int
-		//get product untuk mendapatkan harga
-		err := tx.QueryRow("SELECT id, name, price, stock FROM products WHERE id = $1", item.ProductID).Scan(&productID, &productName, &price, &stock)
+		var taxExempt bool
+		//get product untuk mendapatkan harga, FOR UPDATE menahan lock baris sampai commit
+		//sehingga checkout lain untuk produk yang sama harus menunggu dan membaca stok terbaru
+		err := tx.QueryRow("SELECT id, name, price, stock, is_tax_exempt FROM products WHERE id = $1 FOR UPDATE", item.ProductID).Scan(&productID, &productName, &price, &stock, &taxExempt)
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("Product ID %d NOT FOUND", item.ProductID)
+			return nil, &NotFoundError{Resource: "product", ID: item.ProductID}
 		}
 		if err != nil {
 			return nil, err
 		}
-		//hitung current total = quantity * harga
+		//stok harus cukup agar tidak menjadi negatif
+		if item.Quantity > stock {
+			return nil, &InsufficientStockError{ProductID: productID, ProductName: productName, Have: stock, Need: item.Quantity}
+		}
+		//hitung current total = quantity * harga - diskon item
 		//ditambah ke dalam subtotal
-		subtotal := price * item.Quantity
+		lineTotal := price * item.Quantity
+		if item.Discount > lineTotal {
+			return nil, checkoutRejected("discount %d for product %s exceeds the line total %d", item.Discount, productName, lineTotal)
+		}
+		if exceedsDiscountLimit(item.Discount, lineTotal, maxDiscountPercent) {
+			return nil, &DiscountLimitError{ProductName: productName, Discount: item.Discount, Base: lineTotal, MaxPercent: maxDiscountPercent}
+		}
+		subtotal := lineTotal - item.Discount
 		totalAmount += subtotal
-		//kurangi jumlah stock
-		_, err = tx.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2", item.Quantity, item.ProductID)
+		if !taxExempt {
+			taxableAmount += subtotal
+		}
+		//kurangi jumlah stock, syarat stock >= quantity dicek ulang di UPDATE
+		//karena checkout lain bisa mengurangi stok setelah SELECT di atas
+		var remaining int
+		err = tx.QueryRow("UPDATE products SET stock = stock - $1 WHERE id = $2 AND stock >= $1 RETURNING stock", item.Quantity, item.ProductID).Scan(&remaining)
+		if err == sql.ErrNoRows {
+			var current int
+			if err := tx.QueryRow("SELECT stock FROM products WHERE id = $1", item.ProductID).Scan(&current); err != nil {
+				return nil, err
+			}
+			return nil, &InsufficientStockError{ProductID: productID, ProductName: productName, Have: current, Need: item.Quantity}
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -56,21 +101,98 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 			ProductID:   productID,
 			ProductName: productName,
 			Quantity:    item.Quantity,
+			Discount:    item.Discount,
 			Subtotal:    subtotal,
 		})
 	}
 
+	//diskon transaksi dipotong dari subtotal sebelum loyalty dan pajak
+	subtotal := totalAmount
+	if req.DiscountAmount > subtotal {
+		return nil, checkoutRejected("discount %d exceeds the subtotal %d", req.DiscountAmount, subtotal)
+	}
+	if exceedsDiscountLimit(req.DiscountAmount, subtotal, maxDiscountPercent) {
+		return nil, &DiscountLimitError{Discount: req.DiscountAmount, Base: subtotal, MaxPercent: maxDiscountPercent}
+	}
+	totalAmount -= req.DiscountAmount
+
+	//proses loyalty jika ada nomor HP pelanggan
+	var customerID *int
+	pointsEarned, loyaltyDiscount := 0, 0
+	if req.CustomerPhone != "" {
+		var id, points int
+		//buat pelanggan baru jika belum ada, lalu kunci barisnya agar saldo poin tidak balapan
+		err = tx.QueryRow(`INSERT INTO customers (phone, name) VALUES ($1, $2)
+			ON CONFLICT (phone) DO UPDATE SET name = COALESCE(NULLIF(EXCLUDED.name, ''), customers.name)
+			RETURNING id`, req.CustomerPhone, req.CustomerName).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		err = tx.QueryRow("SELECT points FROM customers WHERE id = $1 FOR UPDATE", id).Scan(&points)
+		if err != nil {
+			return nil, err
+		}
+
+		//validasi saldo poin sebelum ditukar
+		if req.RedeemPoints > points {
+			return nil, checkoutRejected("insufficient points: have %d, want to redeem %d", points, req.RedeemPoints)
+		}
+		loyaltyDiscount = req.RedeemPoints * loyalty.PointValue
+		if loyaltyDiscount > totalAmount {
+			return nil, checkoutRejected("redeemed points worth %d exceed the transaction total %d", loyaltyDiscount, totalAmount)
+		}
+		totalAmount -= loyaltyDiscount
+
+		//poin didapat dari total setelah diskon
+		if loyalty.SpendPerPoint > 0 {
+			pointsEarned = totalAmount / loyalty.SpendPerPoint
+		}
+		_, err = tx.Exec("UPDATE customers SET points = points - $1 + $2 WHERE id = $3", req.RedeemPoints, pointsEarned, id)
+		if err != nil {
+			return nil, err
+		}
+		customerID = &id
+	}
+
+	//pajak dihitung dari bagian yang kena pajak setelah semua diskon, diskon dibagi proporsional ke item
+	tax := 0
+	if req.TaxPercent > 0 && subtotal > 0 {
+		taxableBase := float64(taxableAmount) * float64(totalAmount) / float64(subtotal)
+		tax = int(math.Round(taxableBase * req.TaxPercent / 100))
+	}
+	totalAmount += tax
+
+	//kembalian hanya untuk pembayaran tunai, non-tunai selalu dibayar pas
+	amountPaid := totalAmount
+	if req.PaymentMethod == models.PaymentCash && req.AmountPaid > 0 {
+		if req.AmountPaid < totalAmount {
+			return nil, checkoutRejected("amount paid %d is less than the total %d", req.AmountPaid, totalAmount)
+		}
+		amountPaid = req.AmountPaid
+	}
+
 	//insert transaction
 	var transactionID int
-	err = tx.QueryRow("INSERT INTO transactions (total_amount) VALUES ($1) RETURNING id", totalAmount).Scan(&transactionID)
+	var createdAt time.Time
+	var idempotencyKey *string
+	if req.IdempotencyKey != "" {
+		idempotencyKey = &req.IdempotencyKey
+	}
+	err = tx.QueryRow(`INSERT INTO transactions (subtotal, discount, tax, total_amount, customer_id, points_earned, points_redeemed, loyalty_discount, idempotency_key, payment_method, amount_paid, taxable_subtotal)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at`,
+		subtotal, req.DiscountAmount, tax, totalAmount, customerID, pointsEarned, req.RedeemPoints, loyaltyDiscount, idempotencyKey, req.PaymentMethod, amountPaid, taxableAmount).Scan(&transactionID, &createdAt)
+	if idempotencyKey != nil && uniqueViolationConstraint(err) == "idx_transactions_idempotency_key" {
+		//request kembar sudah commit lebih dulu, rollback agar stok dan poin tidak berkurang dua kali
+		return nil, ErrDuplicateIdempotencyKey
+	}
 	if err != nil {
 		return nil, err
 	}
 	//insert transaction details
 	for i := range details {
 		details[i].TransactionID = transactionID
-		_, err = tx.Exec("INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES ($1, $2, $3, $4)",
-			transactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal)
+		_, err = tx.Exec("INSERT INTO transaction_details (transaction_id, product_id, quantity, discount, subtotal) VALUES ($1, $2, $3, $4, $5)",
+			transactionID, details[i].ProductID, details[i].Quantity, details[i].Discount, details[i].Subtotal)
 		if err != nil {
 			return nil, err
 		}
@@ -81,10 +203,446 @@ func (repo *TransactionRepository) CreateTransaction(items []models.CheckoutItem
 	}
 
 	res = &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		Details:     details,
+		ID:              transactionID,
+		Subtotal:        subtotal,
+		TaxableSubtotal: taxableAmount,
+		ExemptSubtotal:  subtotal - taxableAmount,
+		Discount:        req.DiscountAmount,
+		Tax:             tax,
+		TotalAmount:     totalAmount,
+		CustomerPhone:   req.CustomerPhone,
+		PointsEarned:    pointsEarned,
+		PointsRedeemed:  req.RedeemPoints,
+		LoyaltyDiscount: loyaltyDiscount,
+		PaymentMethod:   req.PaymentMethod,
+		AmountPaid:      amountPaid,
+		Change:          amountPaid - totalAmount,
+		CreatedAt:       createdAt,
+		IdempotencyKey:  req.IdempotencyKey,
+		Status:          models.TransactionStatusCompleted,
+		Details:         details,
 	}
 
 	return res, nil
 }
+
+// GetRecentForProduct mengambil N transaksi terakhir yang berisi produk tertentu, terbaru di awal
+func (repo *TransactionRepository) GetRecentForProduct(productID, limit int) ([]models.ProductSale, error) {
+	rows, err := repo.db.Query(`
+		SELECT t.id, td.quantity, td.subtotal, t.created_at
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		WHERE td.product_id = $1 AND t.voided_at IS NULL
+		ORDER BY t.created_at DESC, t.id DESC
+		LIMIT $2
+	`, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := make([]models.ProductSale, 0)
+	for rows.Next() {
+		var sale models.ProductSale
+		if err := rows.Scan(&sale.TransactionID, &sale.Quantity, &sale.Subtotal, &sale.CreatedAt); err != nil {
+			return nil, err
+		}
+		sales = append(sales, sale)
+	}
+	return sales, nil
+}
+
+// GetLowStockAmong mengambil produk dari daftar ID yang stoknya sudah <= min_stock efektif (produk atau kategori)
+// Dipakai setelah checkout untuk mengetahui produk mana yang perlu dikirim sebagai alert
+func (repo *TransactionRepository) GetLowStockAmong(productIDs []int) ([]models.Product, error) {
+	query := productSelectQuery + " WHERE p.id = ANY($1) AND p.stock <= " + effectiveMinStock
+
+	rows, err := repo.db.Query(query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]models.Product, 0)
+	for rows.Next() {
+		var p models.Product
+		if err := scanProduct(rows, &p); err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	return products, nil
+}
+
+// StreamDetails membaca semua item transaksi dalam range dan memanggil fn untuk setiap baris
+// Baris tidak ditampung di memory agar export range besar tetap ringan. Tanggal dibaca menurut timezone tz
+func (repo *TransactionRepository) StreamDetails(startDate, endDate, tz string, fn func(models.TransactionDetailExport) error) error {
+	rows, err := repo.db.Query(`
+		SELECT t.id, t.created_at, t.payment_method, p.name, td.quantity,
+			COALESCE((td.subtotal + td.discount) / NULLIF(td.quantity, 0), 0) AS unit_price, td.subtotal
+		FROM transaction_details td
+		JOIN transactions t ON t.id = td.transaction_id
+		JOIN products p ON p.id = td.product_id
+		WHERE `+localDate("t.created_at", 3)+` BETWEEN $1 AND $2 AND t.voided_at IS NULL
+		ORDER BY t.created_at, t.id, td.id
+	`, startDate, endDate, tz)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.TransactionDetailExport
+		if err := rows.Scan(&d.TransactionID, &d.CreatedAt, &d.PaymentMethod, &d.ProductName, &d.Quantity, &d.UnitPrice, &d.Subtotal); err != nil {
+			return err
+		}
+		if err := fn(d); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// VoidByDateRange membatalkan semua transaksi yang belum di-void dalam range tanggal
+// Stok produk dikembalikan (dicatat sebagai stock movement "void") dan poin loyalty dikoreksi
+// Semua dilakukan dalam satu transaksi database, gagal di tengah berarti tidak ada yang berubah
+// Tanggal dibaca menurut timezone tz agar range-nya sama dengan report harian
+func (repo *TransactionRepository) VoidByDateRange(startDate, endDate, tz string) (*models.VoidBatchResult, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Tandai transaksi sebagai void dan kunci barisnya sekaligus
+	rows, err := tx.Query(`
+		UPDATE transactions SET voided_at = NOW(), status = $3
+		WHERE voided_at IS NULL AND `+localDate("created_at", 4)+` BETWEEN $1 AND $2
+		RETURNING id, customer_id, points_earned, points_redeemed
+	`, startDate, endDate, models.TransactionStatusVoided, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0)
+	reversals := make([]pointsReversal, 0)
+	for rows.Next() {
+		var id, earned, redeemed int
+		var customerID sql.NullInt64
+		if err := rows.Scan(&id, &customerID, &earned, &redeemed); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		if customerID.Valid && earned != redeemed {
+			reversals = append(reversals, pointsReversal{customerID: int(customerID.Int64), delta: redeemed - earned})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := &models.VoidBatchResult{VoidedCount: len(ids)}
+	if len(ids) == 0 {
+		return result, tx.Commit()
+	}
+
+	result.StockRestored, err = reverseTransactions(tx, ids, reversals, "void")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// pointsReversal adalah perubahan saldo poin pelanggan saat transaksinya dibatalkan
+type pointsReversal struct {
+	customerID int
+	delta      int
+}
+
+// reverseTransactions mengembalikan efek transaksi yang dibatalkan: poin loyalty dan stok produk
+// Stok yang dikembalikan dicatat sebagai stock movement dengan reason; mengembalikan total qty yang dikembalikan
+func reverseTransactions(tx *sql.Tx, ids []int, reversals []pointsReversal, reason string) (int, error) {
+	// Poin yang didapat ditarik kembali dan poin yang ditukar dikembalikan, saldo tidak boleh negatif
+	for _, rv := range reversals {
+		_, err := tx.Exec("UPDATE customers SET points = GREATEST(points + $1, 0) WHERE id = $2", rv.delta, rv.customerID)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Jumlah qty per produk yang harus dikembalikan ke stok
+	restoreRows, err := tx.Query(`
+		SELECT product_id, SUM(quantity)
+		FROM transaction_details
+		WHERE transaction_id = ANY($1)
+		GROUP BY product_id
+		ORDER BY product_id
+	`, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+
+	movements := make([]models.StockMovement, 0)
+	for restoreRows.Next() {
+		var m models.StockMovement
+		if err := restoreRows.Scan(&m.ProductID, &m.Delta); err != nil {
+			restoreRows.Close()
+			return 0, err
+		}
+		m.Reason = reason
+		movements = append(movements, m)
+	}
+	restoreRows.Close()
+	if err := restoreRows.Err(); err != nil {
+		return 0, err
+	}
+
+	restored := 0
+	for i := range movements {
+		m := &movements[i]
+		_, err := tx.Exec("UPDATE products SET stock = stock + $1 WHERE id = $2", m.Delta, m.ProductID)
+		if err != nil {
+			return 0, err
+		}
+
+		err = tx.QueryRow("INSERT INTO stock_movements (product_id, delta, reason) VALUES ($1, $2, $3) RETURNING id, created_at",
+			m.ProductID, m.Delta, m.Reason).Scan(&m.ID, &m.CreatedAt)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := recordAudit(tx, m.ProductID, AuditStockMovement, m); err != nil {
+			return 0, err
+		}
+
+		restored += m.Delta
+	}
+
+	return restored, nil
+}
+
+// CancelTransaction membatalkan satu transaksi: status menjadi cancelled, stok dan poin loyalty dikembalikan
+// voided_at ikut diisi agar transaksi tidak dihitung di laporan, sama seperti void massal
+// Mengembalikan NotFoundError jika transaksi tidak ada dan ErrTransactionCancelled jika sudah dibatalkan/void
+func (repo *TransactionRepository) CancelTransaction(id int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// FOR UPDATE agar dua request cancel bersamaan tidak mengembalikan stok dua kali
+	var customerID sql.NullInt64
+	var earned, redeemed int
+	var voidedAt sql.NullTime
+	err = tx.QueryRow("SELECT customer_id, points_earned, points_redeemed, voided_at FROM transactions WHERE id = $1 FOR UPDATE", id).
+		Scan(&customerID, &earned, &redeemed, &voidedAt)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Resource: "transaction", ID: id}
+	}
+	if err != nil {
+		return err
+	}
+	if voidedAt.Valid {
+		return ErrTransactionCancelled
+	}
+
+	_, err = tx.Exec("UPDATE transactions SET status = $1, voided_at = NOW() WHERE id = $2", models.TransactionStatusCancelled, id)
+	if err != nil {
+		return err
+	}
+
+	reversals := make([]pointsReversal, 0, 1)
+	if customerID.Valid && earned != redeemed {
+		reversals = append(reversals, pointsReversal{customerID: int(customerID.Int64), delta: redeemed - earned})
+	}
+	if _, err := reverseTransactions(tx, []int{id}, reversals, "cancel"); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// transactionSelectQuery adalah SELECT kolom transaksi yang dibaca oleh scanTransaction (urutannya harus sama)
+const transactionSelectQuery = `
+	SELECT t.id, t.subtotal, COALESCE(t.taxable_subtotal, 0), t.discount, t.tax, t.total_amount, COALESCE(c.phone, ''), t.points_earned, t.points_redeemed,
+		t.loyalty_discount, t.payment_method, t.amount_paid, t.created_at, t.voided_at, COALESCE(t.idempotency_key, ''), t.status
+	FROM transactions t
+	LEFT JOIN customers c ON c.id = t.customer_id`
+
+// scanTransaction membaca satu baris hasil transactionSelectQuery ke dalam t (tanpa Details)
+func scanTransaction(row rowScanner, t *models.Transaction) error {
+	var voidedAt sql.NullTime
+	err := row.Scan(&t.ID, &t.Subtotal, &t.TaxableSubtotal, &t.Discount, &t.Tax, &t.TotalAmount, &t.CustomerPhone, &t.PointsEarned, &t.PointsRedeemed,
+		&t.LoyaltyDiscount, &t.PaymentMethod, &t.AmountPaid, &t.CreatedAt, &voidedAt, &t.IdempotencyKey, &t.Status)
+	if err != nil {
+		return err
+	}
+	if voidedAt.Valid {
+		t.VoidedAt = &voidedAt.Time
+	}
+	t.Change = t.AmountPaid - t.TotalAmount
+	t.ExemptSubtotal = t.Subtotal - t.TaxableSubtotal
+	return nil
+}
+
+// GetByID mengambil satu transaksi beserta detailnya (nama produk, qty, subtotal)
+// Mengembalikan NotFoundError jika transaksi dengan ID tersebut tidak ada
+func (repo *TransactionRepository) GetByID(id int) (*models.Transaction, error) {
+	var t models.Transaction
+	err := scanTransaction(repo.db.QueryRow(transactionSelectQuery+" WHERE t.id = $1", id), &t)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "transaction", ID: id}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := []models.Transaction{t}
+	if err := repo.loadDetails(transactions); err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
+
+// GetByIdempotencyKey mengambil transaksi beserta detailnya berdasarkan idempotency key
+func (repo *TransactionRepository) GetByIdempotencyKey(key string) (*models.Transaction, error) {
+	var t models.Transaction
+	err := scanTransaction(repo.db.QueryRow(transactionSelectQuery+" WHERE t.idempotency_key = $1", key), &t)
+	if err == sql.ErrNoRows {
+		return nil, &NotFoundError{Resource: "transaction"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := []models.Transaction{t}
+	if err := repo.loadDetails(transactions); err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
+
+// GetAll mengambil satu halaman riwayat transaksi dari yang terbaru beserta detailnya
+// Mengembalikan total transaksi yang cocok dengan filter untuk pagination. Transaksi void tetap ikut dengan voided_at terisi
+// Filter tanggal dibaca menurut timezone tz
+func (repo *TransactionRepository) GetAll(filter models.TransactionFilter, tz string, limit, offset int) ([]models.Transaction, int, error) {
+	where := ""
+	args := []interface{}{}
+	if filter.StartDate != "" && filter.EndDate != "" {
+		where = " WHERE " + localDate("t.created_at", 3) + " BETWEEN $1 AND $2"
+		args = append(args, filter.StartDate, filter.EndDate, tz)
+	}
+
+	var total int
+	if err := repo.db.QueryRow("SELECT COUNT(*) FROM transactions t"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := transactionSelectQuery + where + fmt.Sprintf(" ORDER BY t.created_at DESC, t.id DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	rows, err := repo.db.Query(query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	transactions := make([]models.Transaction, 0)
+	for rows.Next() {
+		var t models.Transaction
+		if err := scanTransaction(rows, &t); err != nil {
+			return nil, 0, err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := repo.loadDetails(transactions); err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, total, nil
+}
+
+// GetPageByCursor mengambil transaksi dari yang terbaru dengan cursor pagination (id < cursor)
+// cursor 0 berarti mulai dari transaksi paling baru. Transaksi void tetap ikut dengan voided_at terisi
+func (repo *TransactionRepository) GetPageByCursor(cursor, limit int) ([]models.Transaction, error) {
+	rows, err := repo.db.Query(transactionSelectQuery+`
+		WHERE ($1 = 0 OR t.id < $1)
+		ORDER BY t.id DESC
+		LIMIT $2
+	`, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := make([]models.Transaction, 0)
+	for rows.Next() {
+		var t models.Transaction
+		if err := scanTransaction(rows, &t); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := repo.loadDetails(transactions); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// loadDetails mengisi Details setiap transaksi dengan satu query untuk semua ID (menghindari N+1 query)
+func (repo *TransactionRepository) loadDetails(transactions []models.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(transactions))
+	index := make(map[int]int, len(transactions))
+	for i := range transactions {
+		ids[i] = transactions[i].ID
+		index[transactions[i].ID] = i
+		transactions[i].Details = make([]models.TransactionDetails, 0)
+	}
+
+	// LEFT JOIN karena produk bisa sudah dihapus, nama produk menjadi kosong
+	rows, err := repo.db.Query(`
+		SELECT td.id, td.transaction_id, td.product_id, COALESCE(p.name, ''), td.quantity, td.discount, td.subtotal
+		FROM transaction_details td
+		LEFT JOIN products p ON p.id = td.product_id
+		WHERE td.transaction_id = ANY($1)
+		ORDER BY td.id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d models.TransactionDetails
+		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.Quantity, &d.Discount, &d.Subtotal); err != nil {
+			return err
+		}
+		i := index[d.TransactionID]
+		transactions[i].Details = append(transactions[i].Details, d)
+	}
+	return rows.Err()
+}
+
+// exceedsDiscountLimit memeriksa apakah discount melebihi maxPercent persen dari base
+func exceedsDiscountLimit(discount, base int, maxPercent float64) bool {
+	return float64(discount)*100 > maxPercent*float64(base)
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"kasir-api/models"
+)
+
+// TrendPeriods adalah whitelist nilai period untuk laporan tren beserta unit date_trunc di PostgreSQL
+var TrendPeriods = map[string]string{
+	"daily":   "day",
+	"weekly":  "week",
+	"monthly": "month",
+}
+
+// querySalesTrend mengambil qty dan revenue per periode untuk N periode terakhir termasuk periode berjalan
+// filterColumn adalah kolom yang dicocokkan dengan id (td.product_id atau p.category_id), bukan input user
+// Periode tanpa penjualan tetap muncul dengan nilai 0 (zero-fill lewat generate_series)
+// Periode dan "hari ini" dihitung menurut timezone tz agar sama dengan report harian
+func querySalesTrend(db *sql.DB, filterColumn string, id int, period string, count int, tz string) ([]models.TrendPoint, error) {
+	unit, ok := TrendPeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("invalid period %s", period)
+	}
+
+	rows, err := db.Query(fmt.Sprintf(`
+		WITH periods AS (
+			SELECT generate_series(
+				date_trunc($2::text, DATE(NOW() AT TIME ZONE $4)::timestamp) - ($3::int - 1) * ('1 ' || $2::text)::interval,
+				date_trunc($2::text, DATE(NOW() AT TIME ZONE $4)::timestamp),
+				('1 ' || $2::text)::interval
+			) AS period_start
+		),
+		sales AS (
+			SELECT date_trunc($2::text, %[2]s) AS period_start,
+				SUM(td.quantity) AS qty, SUM(td.subtotal) AS revenue
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			LEFT JOIN products p ON p.id = td.product_id
+			WHERE %[1]s = $1 AND t.voided_at IS NULL
+				AND %[2]s >= (SELECT MIN(period_start) FROM periods)
+			GROUP BY 1
+		)
+		SELECT to_char(pr.period_start, 'YYYY-MM-DD'), COALESCE(s.qty, 0), COALESCE(s.revenue, 0)
+		FROM periods pr
+		LEFT JOIN sales s ON s.period_start = pr.period_start
+		ORDER BY pr.period_start
+	`, filterColumn, localTime("t.created_at", 4)), id, unit, count, tz)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]models.TrendPoint, 0, count)
+	for rows.Next() {
+		var tp models.TrendPoint
+		if err := rows.Scan(&tp.PeriodStart, &tp.Quantity, &tp.Revenue); err != nil {
+			return nil, err
+		}
+		points = append(points, tp)
+	}
+	return points, rows.Err()
+}
@@ -21,6 +21,17 @@ func (s *CategoryService) GetByID(id int) (*models.Category, error) {
 	return s.repo.GetByID(id)
 }
 
+func (s *CategoryService) GetByIDs(ids []int) ([]models.Category, error) {
+	if len(ids) == 0 {
+		return []models.Category{}, nil
+	}
+	return s.repo.GetByIDs(ids)
+}
+
+func (s *CategoryService) GetByName(name string) (*models.Category, error) {
+	return s.repo.GetByName(name)
+}
+
 func (s *CategoryService) Create(data *models.Category) error {
 	return s.repo.Create(data)
 }
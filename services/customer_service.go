@@ -0,0 +1,21 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+)
+
+// CustomerService menangani business logic untuk pelanggan loyalty
+type CustomerService struct {
+	repo *repositories.CustomerRepository
+}
+
+// NewCustomerService membuat instance baru dari CustomerService
+func NewCustomerService(repo *repositories.CustomerRepository) *CustomerService {
+	return &CustomerService{repo: repo}
+}
+
+// GetByPhone mengambil pelanggan beserta saldo poinnya
+func (s *CustomerService) GetByPhone(phone string) (*models.Customer, error) {
+	return s.repo.GetByPhone(phone)
+}
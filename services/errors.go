@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrValidation menandai error karena input tidak valid (bukan error database)
+// Handler memakai errors.Is(err, ErrValidation) untuk membalas 400 alih-alih 500
+var ErrValidation = errors.New("validation failed")
+
+// ErrDiscountLimit menandai checkout yang diskonnya melebihi MAX_DISCOUNT_PERCENT
+// Handler membalas 403 karena kasir tidak berwenang memberi diskon sebesar itu
+var ErrDiscountLimit = errors.New("discount limit exceeded")
+
+// validationError adalah error validasi dengan pesan yang bisa langsung ditampilkan ke user
+type validationError struct {
+	msg string
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+// Is membuat errors.Is(err, ErrValidation) bernilai true untuk semua validationError
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// newValidationError membuat error validasi dengan format seperti fmt.Errorf
+func newValidationError(format string, args ...interface{}) error {
+	return &validationError{msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"fmt"
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+// ImportService memvalidasi dokumen import katalog sebelum disimpan
+type ImportService struct {
+	repo *repositories.ImportRepository
+}
+
+// NewImportService membuat instance baru dari ImportService
+func NewImportService(repo *repositories.ImportRepository) *ImportService {
+	return &ImportService{repo: repo}
+}
+
+// ImportCatalog membuang baris yang tidak valid (dicatat sebagai error) lalu mengimpor sisanya
+func (s *ImportService) ImportCatalog(req *models.ImportRequest) (*models.ImportResult, error) {
+	rowErrors := make([]string, 0)
+
+	categories := make([]models.ImportCategory, 0, len(req.Categories))
+	for i, c := range req.Categories {
+		c.Row = i
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" {
+			rowErrors = append(rowErrors, fmt.Sprintf("categories[%d]: name is required", i))
+			continue
+		}
+		categories = append(categories, c)
+	}
+
+	products := make([]models.ImportProduct, 0, len(req.Products))
+	for i, p := range req.Products {
+		p.Row = i
+		p.Name = strings.TrimSpace(p.Name)
+		p.CategoryName = strings.TrimSpace(p.CategoryName)
+		switch {
+		case p.Name == "":
+			rowErrors = append(rowErrors, fmt.Sprintf("products[%d]: name is required", i))
+		case p.Price < 0:
+			rowErrors = append(rowErrors, fmt.Sprintf("products[%d]: price must not be negative", i))
+		case p.Stock < 0:
+			rowErrors = append(rowErrors, fmt.Sprintf("products[%d]: stock must not be negative", i))
+		default:
+			products = append(products, p)
+		}
+	}
+
+	result, err := s.repo.ImportCatalog(&models.ImportRequest{Categories: categories, Products: products})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Errors = append(rowErrors, result.Errors...)
+	return result, nil
+}
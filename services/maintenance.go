@@ -0,0 +1,26 @@
+package services
+
+import "sync/atomic"
+
+// MaintenanceMode menyimpan flag maintenance di memory
+// Saat aktif, checkout ditolak sementara endpoint baca tetap berjalan
+type MaintenanceMode struct {
+	enabled atomic.Bool
+}
+
+// NewMaintenanceMode membuat flag maintenance dengan nilai awal dari config
+func NewMaintenanceMode(enabled bool) *MaintenanceMode {
+	m := &MaintenanceMode{}
+	m.enabled.Store(enabled)
+	return m
+}
+
+// Enabled mengembalikan true jika aplikasi sedang dalam mode maintenance
+func (m *MaintenanceMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Set mengaktifkan atau menonaktifkan mode maintenance
+func (m *MaintenanceMode) Set(enabled bool) {
+	m.enabled.Store(enabled)
+}
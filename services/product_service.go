@@ -1,43 +1,271 @@
 package services
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"strconv"
+	"strings"
 )
 
 // ProductService menangani business logic untuk produk
 // Bertugas sebagai penghubung antara handler dan repository
 type ProductService struct {
 	repo *repositories.ProductRepository
+	// timezone toko (APP_TIMEZONE) untuk menentukan periode tren penjualan, sama dengan report
+	timezone string
 }
 
 // NewProductService membuat instance baru dari ProductService
-func NewProductService(repo *repositories.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(repo *repositories.ProductRepository, timezone string) *ProductService {
+	return &ProductService{repo: repo, timezone: timezone}
 }
 
 // GetAll memanggil repository untuk mengambil semua produk
 // Bisa ditambahkan validasi atau business logic di sini jika diperlukan
-func (s *ProductService) GetAll(name string) ([]models.Product, error) {
-	return s.repo.GetAll(name)
+func (s *ProductService) GetAll(filter models.ProductFilter, page, size int) (*models.ProductPage, error) {
+	products, total, err := s.repo.GetAll(filter, size, (page-1)*size)
+	if err != nil {
+		return nil, err
+	}
+	setStockStatuses(products)
+	return &models.ProductPage{Data: products, Total: total, Page: page, Size: size}, nil
+}
+
+// exportPageSize adalah jumlah produk yang dibaca per query saat export
+const exportPageSize = 500
+
+// Export membaca semua produk yang cocok dengan filter per halaman dan memanggil fn untuk setiap produk
+// Dibaca bertahap agar katalog besar tidak dimuat sekaligus ke memori
+func (s *ProductService) Export(filter models.ProductFilter, fn func(models.Product) error) error {
+	for offset := 0; ; offset += exportPageSize {
+		products, _, err := s.repo.GetAll(filter, exportPageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, p := range products {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+		if len(products) < exportPageSize {
+			return nil
+		}
+	}
 }
 
 // Create memvalidasi dan menyimpan produk baru melalui repository
 // Di sini bisa ditambahkan validasi business logic seperti cek nama duplikat, validasi harga, dll
 func (s *ProductService) Create(data *models.Product) error {
-	return s.repo.Create(data)
+	if err := validateProduct(data); err != nil {
+		return err
+	}
+
+	// Cek lebih dulu agar pesan error menyebut produk yang sudah ada
+	// Unique index di database tetap menjadi pengaman jika dua request masuk bersamaan
+	existing, err := s.repo.GetByName(data.Name)
+	if err == nil {
+		return &repositories.ConflictError{Resource: "product", Field: "name", Value: existing.Name}
+	}
+	var notFound *repositories.NotFoundError
+	if !errors.As(err, &notFound) {
+		return err
+	}
+
+	err = s.repo.Create(data)
+	if err != nil {
+		return err
+	}
+	setStockStatus(data)
+	data.Margin = data.Price - data.CostPrice
+	return nil
+}
+
+// validateProduct merapikan name dan SKU lalu memvalidasi data produk sebelum disimpan
+// Semua error yang dikembalikan adalah ErrValidation
+func validateProduct(product *models.Product) error {
+	product.Name = strings.TrimSpace(product.Name)
+	product.SKU = strings.TrimSpace(product.SKU)
+	if product.Name == "" {
+		return newValidationError("name is required")
+	}
+	if product.Price < 0 {
+		return newValidationError("price must not be negative")
+	}
+	if product.Stock < 0 {
+		return newValidationError("stock must not be negative")
+	}
+	return validateCostPrice(product)
+}
+
+// validateCostPrice menolak harga modal negatif atau lebih besar dari harga jual
+// Harga modal di atas harga jual hampir selalu salah input, bukan produk yang memang dijual rugi
+func validateCostPrice(product *models.Product) error {
+	if product.CostPrice < 0 {
+		return newValidationError("cost_price must not be negative")
+	}
+	if product.CostPrice > product.Price {
+		return newValidationError("cost_price (%d) must not be greater than price (%d)", product.CostPrice, product.Price)
+	}
+	return nil
+}
+
+// maxBulkCreate adalah jumlah produk maksimum dalam satu bulk create
+const maxBulkCreate = 1000
+
+// CreateBatch memvalidasi semua produk terlebih dahulu lalu menyimpannya dalam satu transaksi
+// Setiap item divalidasi dengan aturan yang sama seperti create satu produk (validateProduct)
+// Jika ada item yang tidak valid, seluruh batch ditolak dengan pesan berisi index item tersebut
+func (s *ProductService) CreateBatch(products []models.Product) (*models.BulkCreateResult, error) {
+	if len(products) == 0 {
+		return nil, newValidationError("products must not be empty")
+	}
+	if len(products) > maxBulkCreate {
+		return nil, newValidationError("products must not contain more than %d items", maxBulkCreate)
+	}
+
+	invalid := make([]string, 0)
+	for i := range products {
+		if err := validateProduct(&products[i]); err != nil {
+			invalid = append(invalid, fmt.Sprintf("index %d: %v", i, err))
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, newValidationError("invalid products: %s", strings.Join(invalid, "; "))
+	}
+
+	if err := s.repo.CreateBatch(products); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	return &models.BulkCreateResult{Created: len(products), IDs: ids}, nil
+}
+
+// ImportCSV membaca CSV dengan kolom name,price,stock,category_id (baris pertama header) lalu menyimpan setiap baris valid
+// Baris yang gagal validasi atau gagal disimpan dikumpulkan di rowErrors tanpa menghentikan import
+// err hanya terisi jika file CSV tidak bisa dibaca sama sekali
+func (s *ProductService) ImportCSV(r io.Reader) (imported int, rowErrors []string, err error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rowErrors = make([]string, 0)
+	line := 0
+	for {
+		record, readErr := reader.Read()
+		if readErr == io.EOF {
+			break
+		}
+		line++
+		if readErr != nil {
+			var parseErr *csv.ParseError
+			if errors.As(readErr, &parseErr) {
+				rowErrors = append(rowErrors, fmt.Sprintf("line %d: %v", parseErr.Line, parseErr.Err))
+				continue
+			}
+			return imported, rowErrors, readErr
+		}
+
+		// Baris pertama adalah header
+		if line == 1 {
+			continue
+		}
+
+		product, rowErr := parseProductCSVRecord(record)
+		if rowErr != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: %v", line, rowErr))
+			continue
+		}
+
+		if createErr := s.repo.Create(product); createErr != nil {
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: %v", line, createErr))
+			continue
+		}
+		imported++
+	}
+
+	return imported, rowErrors, nil
+}
+
+// parseProductCSVRecord mengubah satu baris CSV (name,price,stock,category_id) menjadi Product
+// category_id boleh kosong untuk produk tanpa kategori
+func parseProductCSVRecord(record []string) (*models.Product, error) {
+	if len(record) < 3 || len(record) > 4 {
+		return nil, errors.New("expected columns name,price,stock,category_id")
+	}
+	for i := range record {
+		record[i] = strings.TrimSpace(record[i])
+	}
+
+	product := &models.Product{Name: record[0]}
+	if product.Name == "" {
+		return nil, errors.New("name is required")
+	}
+
+	price, err := strconv.Atoi(record[1])
+	if err != nil || price < 0 {
+		return nil, errors.New("price must be a non-negative integer")
+	}
+	product.Price = price
+
+	stock, err := strconv.Atoi(record[2])
+	if err != nil || stock < 0 {
+		return nil, errors.New("stock must be a non-negative integer")
+	}
+	product.Stock = stock
+
+	if len(record) == 4 && record[3] != "" {
+		categoryID, err := strconv.Atoi(record[3])
+		if err != nil || categoryID <= 0 {
+			return nil, errors.New("category_id must be a positive integer")
+		}
+		product.CategoryID = &categoryID
+	}
+
+	return product, nil
 }
 
 // GetByID memanggil repository untuk mengambil produk berdasarkan ID
 // Bisa ditambahkan business logic tambahan jika diperlukan
 func (s *ProductService) GetByID(id int) (*models.Product, error) {
-	return s.repo.GetByID(id)
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	setStockStatus(product)
+	return product, nil
+}
+
+// GetBySKU mengambil produk berdasarkan SKU hasil scan barcode
+func (s *ProductService) GetBySKU(sku string) (*models.Product, error) {
+	product, err := s.repo.GetBySKU(sku)
+	if err != nil {
+		return nil, err
+	}
+	setStockStatus(product)
+	return product, nil
 }
 
 // Update memvalidasi dan memperbarui data produk melalui repository
 // Bisa ditambahkan validasi seperti cek apakah produk ada, validasi perubahan data, dll
 func (s *ProductService) Update(product *models.Product) error {
-	return s.repo.Update(product)
+	if err := validateProduct(product); err != nil {
+		return err
+	}
+	err := s.repo.Update(product)
+	if err != nil {
+		return err
+	}
+	setStockStatus(product)
+	product.Margin = product.Price - product.CostPrice
+	return nil
 }
 
 // Delete menghapus produk melalui repository
@@ -45,3 +273,392 @@ func (s *ProductService) Update(product *models.Product) error {
 func (s *ProductService) Delete(id int) error {
 	return s.repo.Delete(id)
 }
+
+// PartialUpdate memvalidasi fields lalu mengubah hanya kolom tersebut pada satu produk
+// Mengembalikan produk setelah diupdate
+func (s *ProductService) PartialUpdate(id int, fields map[string]interface{}) (*models.Product, error) {
+	fields, err := normalizeProductFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validatePatchedPrice(id, fields); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.PartialUpdate(id, fields); err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(id)
+}
+
+// BatchPartialUpdate memvalidasi setiap item patch lalu menerapkannya dalam satu transaksi
+// Item dengan ID tidak valid, fields kosong, atau kolom di luar whitelist ditolak sebelum menyentuh database
+func (s *ProductService) BatchPartialUpdate(patches []models.ProductPatch) ([]models.Product, error) {
+	if len(patches) == 0 {
+		return nil, newValidationError("patch list is empty")
+	}
+
+	for i := range patches {
+		if patches[i].ID <= 0 {
+			return nil, newValidationError("item %d: invalid product id", i)
+		}
+		fields, err := normalizeProductFields(patches[i].Fields)
+		if err != nil {
+			return nil, newValidationError("item %d: %v", i, err)
+		}
+		if err := s.validatePatchedPrice(patches[i].ID, fields); err != nil {
+			if errors.Is(err, ErrValidation) {
+				return nil, newValidationError("item %d: %v", i, err)
+			}
+			return nil, err
+		}
+		patches[i].Fields = fields
+	}
+
+	products, err := s.repo.BatchPartialUpdate(patches)
+	if err != nil {
+		return nil, err
+	}
+	setStockStatuses(products)
+	return products, nil
+}
+
+// BulkSetMinStock memvalidasi lalu mengubah min_stock beberapa produk sekaligus
+// product_id yang muncul lebih dari sekali hanya diterapkan sekali dengan nilai terakhir
+func (s *ProductService) BulkSetMinStock(updates []models.MinStockUpdate) (*models.BulkMinStockResult, error) {
+	if len(updates) == 0 {
+		return nil, newValidationError("updates must not be empty")
+	}
+
+	unique := make([]models.MinStockUpdate, 0, len(updates))
+	index := make(map[int]int, len(updates))
+	for _, u := range updates {
+		if u.ProductID <= 0 {
+			return nil, newValidationError("product_id is required")
+		}
+		if u.MinStock == nil || *u.MinStock < 0 {
+			return nil, newValidationError("min_stock for product %d must be a non-negative number", u.ProductID)
+		}
+		if i, ok := index[u.ProductID]; ok {
+			unique[i] = u
+			continue
+		}
+		index[u.ProductID] = len(unique)
+		unique = append(unique, u)
+	}
+
+	updated, err := s.repo.BulkSetMinStock(unique)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BulkMinStockResult{Updated: updated}, nil
+}
+
+// SetMinStockByCategory mengubah min_stock semua produk dalam satu kategori
+func (s *ProductService) SetMinStockByCategory(req models.CategoryMinStockUpdate) (*models.BulkMinStockResult, error) {
+	if req.CategoryID == nil || *req.CategoryID <= 0 {
+		return nil, newValidationError("category_id is required")
+	}
+	if req.MinStock == nil || *req.MinStock < 0 {
+		return nil, newValidationError("min_stock must be a non-negative number")
+	}
+
+	updated, err := s.repo.SetMinStockByCategory(*req.CategoryID, *req.MinStock)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BulkMinStockResult{Updated: updated}, nil
+}
+
+// SetOpeningStock memvalidasi saldo stok awal lalu menyimpannya sebagai stock movement opening_balance
+func (s *ProductService) SetOpeningStock(items []models.OpeningStockItem) ([]models.StockMovement, error) {
+	if len(items) == 0 {
+		return nil, newValidationError("items must not be empty")
+	}
+
+	seen := make(map[int]bool, len(items))
+	for _, item := range items {
+		if item.ProductID <= 0 {
+			return nil, newValidationError("product_id is required")
+		}
+		if seen[item.ProductID] {
+			return nil, newValidationError("duplicate product_id %d", item.ProductID)
+		}
+		seen[item.ProductID] = true
+		if item.Stock == nil || *item.Stock < 0 {
+			return nil, newValidationError("stock for product %d must be a non-negative number", item.ProductID)
+		}
+	}
+
+	movements, err := s.repo.SetOpeningStock(items)
+	var history *repositories.StockHistoryError
+	if errors.As(err, &history) {
+		return nil, newValidationError("%s", history.Error())
+	}
+	return movements, err
+}
+
+// ReconcileStock membandingkan stok tercatat dengan hasil hitung ulang riwayat, dan mengoreksinya jika fix true
+func (s *ProductService) ReconcileStock(fix bool) (*models.StockReconcileResult, error) {
+	return s.repo.ReconcileStock(fix)
+}
+
+// validatePatchedPrice menolak harga baru di bawah harga modal produk saat ini, sama seperti validateCostPrice
+func (s *ProductService) validatePatchedPrice(id int, fields map[string]interface{}) error {
+	price, ok := fields["price"].(int)
+	if !ok {
+		return nil
+	}
+
+	current, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if current.CostPrice > price {
+		return newValidationError("price (%d) must not be less than cost_price (%d)", price, current.CostPrice)
+	}
+	return nil
+}
+
+// normalizeProductFields memastikan fields partial update hanya berisi kolom yang diizinkan
+// dan mengubah angka dari JSON (float64) menjadi int; semua error yang dikembalikan adalah ErrValidation
+func normalizeProductFields(fields map[string]interface{}) (map[string]interface{}, error) {
+	if len(fields) == 0 {
+		return nil, newValidationError("fields must not be empty")
+	}
+
+	normalized := make(map[string]interface{}, len(fields))
+	for column, value := range fields {
+		if !repositories.ProductPatchableColumns[column] {
+			return nil, newValidationError("unknown field %s", column)
+		}
+
+		switch column {
+		case "name":
+			name, ok := value.(string)
+			if !ok || strings.TrimSpace(name) == "" {
+				return nil, newValidationError("name must be a non-empty string")
+			}
+			normalized[column] = name
+		case "is_tax_exempt":
+			exempt, ok := value.(bool)
+			if !ok {
+				return nil, newValidationError("is_tax_exempt must be a boolean")
+			}
+			normalized[column] = exempt
+		case "category_id", "min_stock":
+			// category_id dan min_stock boleh null (lepas dari kategori / tanpa batas stok minimum)
+			if value == nil {
+				normalized[column] = nil
+				continue
+			}
+			n, ok := toInt(value)
+			if !ok {
+				return nil, newValidationError("%s must be an integer or null", column)
+			}
+			if column == "min_stock" && n < 0 {
+				return nil, newValidationError("min_stock must not be negative")
+			}
+			normalized[column] = n
+		default:
+			n, ok := toInt(value)
+			if !ok {
+				return nil, newValidationError("%s must be an integer", column)
+			}
+			if n < 0 {
+				return nil, newValidationError("%s must not be negative", column)
+			}
+			normalized[column] = n
+		}
+	}
+
+	return normalized, nil
+}
+
+// toInt mengubah angka hasil decode JSON (float64) menjadi int, menolak angka pecahan
+func toInt(value interface{}) (int, bool) {
+	f, ok := value.(float64)
+	if !ok || f != float64(int(f)) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+// WriteOff mengosongkan stok produk dengan alasan yang wajib diisi
+func (s *ProductService) WriteOff(id int, reason string) (*models.StockMovement, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, newValidationError("reason is required")
+	}
+
+	movement, err := s.repo.WriteOff(id, reason)
+	if errors.Is(err, repositories.ErrStockAlreadyZero) {
+		return nil, newValidationError("%s", err.Error())
+	}
+	return movement, err
+}
+
+// AdjustStock memvalidasi lalu menerapkan koreksi stok manual (barang rusak, hasil hitung ulang, dll)
+func (s *ProductService) AdjustStock(id, delta int, reason string) (*models.StockAdjustment, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, newValidationError("reason is required")
+	}
+	if delta == 0 {
+		return nil, newValidationError("delta must not be zero")
+	}
+
+	adjustment, err := s.repo.AdjustStock(id, delta, reason)
+	var insufficient *repositories.InsufficientStockError
+	if errors.As(err, &insufficient) {
+		return nil, newValidationError("adjustment would make stock negative: have %d, delta %d", insufficient.Have, delta)
+	}
+	return adjustment, err
+}
+
+// GetSalesVelocity menghitung rata-rata penjualan per hari dan perkiraan hari sampai stok habis
+func (s *ProductService) GetSalesVelocity(id, days int) (*models.ProductVelocity, error) {
+	velocity, err := s.repo.GetSalesVelocity(id, days)
+	if err != nil {
+		return nil, err
+	}
+
+	velocity.AvgPerDay = float64(velocity.TotalSold) / float64(days)
+	if velocity.AvgPerDay > 0 {
+		stockout := float64(velocity.Stock) / velocity.AvgPerDay
+		velocity.DaysUntilStockout = &stockout
+	}
+
+	return velocity, nil
+}
+
+// GetExpiring mengambil produk yang akan kadaluarsa dalam N hari
+func (s *ProductService) GetExpiring(days int) ([]models.Product, error) {
+	products, err := s.repo.GetExpiring(days)
+	if err != nil {
+		return nil, err
+	}
+	setStockStatuses(products)
+	return products, nil
+}
+
+// GetFavorites mengambil produk yang ditandai favorit
+func (s *ProductService) GetFavorites() ([]models.Product, error) {
+	products, err := s.repo.GetFavorites()
+	if err != nil {
+		return nil, err
+	}
+	setStockStatuses(products)
+	return products, nil
+}
+
+// GetLowStock mengambil produk yang stoknya <= threshold
+func (s *ProductService) GetLowStock(threshold int) ([]models.Product, error) {
+	products, err := s.repo.GetLowStock(threshold)
+	if err != nil {
+		return nil, err
+	}
+	setStockStatuses(products)
+	return products, nil
+}
+
+// GetOutOfStock mengambil produk yang tidak bisa dijual karena stoknya habis
+func (s *ProductService) GetOutOfStock() ([]models.Product, error) {
+	products, err := s.repo.GetOutOfStock()
+	if err != nil {
+		return nil, err
+	}
+	setStockStatuses(products)
+	return products, nil
+}
+
+// ToggleFavorite membalik status favorit produk lalu mengembalikan produk terbaru
+func (s *ProductService) ToggleFavorite(id int) (*models.Product, error) {
+	err := s.repo.ToggleFavorite(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetByID(id)
+}
+
+// GetGrouped mengelompokkan semua produk berdasarkan kategorinya
+// Produk tanpa kategori dikumpulkan di grup "Uncategorized" (id 0) pada urutan terakhir
+func (s *ProductService) GetGrouped() ([]models.ProductGroup, error) {
+	products, descriptions, err := s.repo.GetAllWithCategory()
+	if err != nil {
+		return nil, err
+	}
+	setStockStatuses(products)
+
+	groups := make([]models.ProductGroup, 0)
+	// index grup berdasarkan category id, 0 untuk Uncategorized
+	indexByCategory := make(map[int]int)
+	for i, p := range products {
+		category := models.Category{Name: "Uncategorized"}
+		if p.CategoryID != nil {
+			category = models.Category{ID: *p.CategoryID, Name: p.CategoryName, Description: descriptions[i]}
+		}
+
+		idx, ok := indexByCategory[category.ID]
+		if !ok {
+			idx = len(groups)
+			indexByCategory[category.ID] = idx
+			groups = append(groups, models.ProductGroup{Category: category, Products: make([]models.Product, 0)})
+		}
+		groups[idx].Products = append(groups[idx].Products, p)
+	}
+
+	return groups, nil
+}
+
+// GetAuditTrail mengambil riwayat perubahan produk secara kronologis
+func (s *ProductService) GetAuditTrail(id int) ([]models.ProductAudit, error) {
+	return s.repo.GetAuditTrail(id)
+}
+
+// GetStockStatus mengambil ringkasan stok beserta statusnya untuk produk tertentu atau semua produk
+func (s *ProductService) GetStockStatus(ids []int) ([]models.ProductStockStatus, error) {
+	levels, err := s.repo.GetStockLevels(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range levels {
+		levels[i].Status = StockStatus(levels[i].Stock, levels[i].MinStock)
+	}
+	return levels, nil
+}
+
+// GetSalesTrend mengambil tren penjualan produk per periode (daily, weekly, monthly)
+func (s *ProductService) GetSalesTrend(id int, period string, count int) (*models.ProductTrend, error) {
+	points, err := s.repo.GetSalesTrend(id, period, count, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ProductTrend{ProductID: id, Period: period, Points: points}, nil
+}
+
+// StockStatus menentukan status stok produk:
+//   - out_of_stock: stock <= 0
+//   - low: stock <= min_stock efektif (hanya jika produk atau kategorinya punya min_stock)
+//   - available: selain kondisi di atas
+func StockStatus(stock int, minStock *int) string {
+	if stock <= 0 {
+		return models.StockStatusOutOfStock
+	}
+	if minStock != nil && stock <= *minStock {
+		return models.StockStatusLow
+	}
+	return models.StockStatusAvailable
+}
+
+// setStockStatus mengisi field StockStatus agar logika yang sama dipakai di semua endpoint produk
+func setStockStatus(product *models.Product) {
+	product.StockStatus = StockStatus(product.Stock, product.EffectiveMinStock)
+}
+
+func setStockStatuses(products []models.Product) {
+	for i := range products {
+		setStockStatus(&products[i])
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeProductFieldsRejectsInvalidValues(t *testing.T) {
+	cases := []struct {
+		name   string
+		fields map[string]interface{}
+	}{
+		{"empty fields", map[string]interface{}{}},
+		{"unknown field", map[string]interface{}{"cost": float64(1)}},
+		{"negative stock", map[string]interface{}{"stock": float64(-5)}},
+		{"negative price", map[string]interface{}{"price": float64(-1)}},
+		{"negative min_stock", map[string]interface{}{"min_stock": float64(-1)}},
+		{"fractional stock", map[string]interface{}{"stock": 1.5}},
+		{"empty name", map[string]interface{}{"name": " "}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := normalizeProductFields(tc.fields)
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("err = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
+
+func TestNormalizeProductFieldsConvertsNumbers(t *testing.T) {
+	fields, err := normalizeProductFields(map[string]interface{}{
+		"price":     float64(5000),
+		"stock":     float64(0),
+		"min_stock": nil,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fields["price"] != 5000 || fields["stock"] != 0 || fields["min_stock"] != nil {
+		t.Fatalf("unexpected fields: %#v", fields)
+	}
+}
+
+// Bulk create memakai validasi yang sama dengan create satu produk, termasuk stok negatif
+func TestCreateBatchRejectsNegativeStock(t *testing.T) {
+	s := NewProductService(nil, "Asia/Jakarta")
+	_, err := s.CreateBatch([]models.Product{
+		{Name: "Indomie", Price: 3000, Stock: 10},
+		{Name: "Aqua", Price: 4000, Stock: -1},
+	})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("err = %v, want ErrValidation", err)
+	}
+	if !strings.Contains(err.Error(), "index 1: stock must not be negative") {
+		t.Fatalf("err = %q, want message for index 1", err)
+	}
+}
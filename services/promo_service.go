@@ -0,0 +1,35 @@
+package services
+
+import (
+	"kasir-api/models"
+	"kasir-api/repositories"
+	"strings"
+)
+
+// PromoService menangani business logic untuk promo set (snapshot harga)
+type PromoService struct {
+	repo *repositories.PromoRepository
+}
+
+// NewPromoService membuat instance baru dari PromoService
+func NewPromoService(repo *repositories.PromoRepository) *PromoService {
+	return &PromoService{repo: repo}
+}
+
+// Snapshot menyimpan harga semua produk saat ini dengan nama promo set
+// Nama tidak boleh kosong atau mengandung "/" karena dipakai di URL apply
+func (s *PromoService) Snapshot(name string) (*models.PromoSnapshotResult, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, newValidationError("name is required")
+	}
+	if strings.Contains(name, "/") {
+		return nil, newValidationError("name must not contain '/'")
+	}
+	return s.repo.Snapshot(name)
+}
+
+// Apply menerapkan harga dari promo set ke produk yang masih ada
+func (s *PromoService) Apply(name string) (*models.PromoApplyResult, error) {
+	return s.repo.Apply(name)
+}
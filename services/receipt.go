@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// receiptWidth adalah jumlah karakter per baris struk, sesuai printer thermal 58mm
+const receiptWidth = 32
+
+// GenerateReceipt menyusun struk teks lebar tetap untuk transaksi, siap dikirim ke printer thermal
+// Mengembalikan NotFoundError jika transaksi tidak ada
+func (s *TransactionService) GenerateReceipt(id int) (string, error) {
+	t, err := s.repo.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+
+	separator := strings.Repeat("-", receiptWidth)
+	var b strings.Builder
+
+	b.WriteString(receiptCenter(strings.ToUpper(s.storeName)) + "\n")
+	b.WriteString(separator + "\n")
+	b.WriteString(fmt.Sprintf("No   : %d\n", t.ID))
+	b.WriteString(fmt.Sprintf("Tgl  : %s\n", t.CreatedAt.Format("02-01-2006 15:04")))
+	if t.CustomerPhone != "" {
+		b.WriteString(fmt.Sprintf("Plg  : %s\n", t.CustomerPhone))
+	}
+	b.WriteString(separator + "\n")
+
+	for _, d := range t.Details {
+		b.WriteString(receiptTruncate(d.ProductName) + "\n")
+		unitPrice := 0
+		if d.Quantity > 0 {
+			unitPrice = (d.Subtotal + d.Discount) / d.Quantity
+		}
+		b.WriteString(receiptLine(fmt.Sprintf("  %d x %s", d.Quantity, formatRupiah(unitPrice)), formatRupiah(d.Subtotal+d.Discount)))
+		if d.Discount > 0 {
+			b.WriteString(receiptLine("  Diskon", "-"+formatRupiah(d.Discount)))
+		}
+	}
+	b.WriteString(separator + "\n")
+
+	b.WriteString(receiptLine("Subtotal", formatRupiah(t.Subtotal)))
+	if t.Discount > 0 {
+		b.WriteString(receiptLine("Diskon", "-"+formatRupiah(t.Discount)))
+	}
+	if t.LoyaltyDiscount > 0 {
+		b.WriteString(receiptLine(fmt.Sprintf("Poin (%d)", t.PointsRedeemed), "-"+formatRupiah(t.LoyaltyDiscount)))
+	}
+	if t.Tax > 0 {
+		// Rincian dasar pajak: item kena pajak dan item bebas pajak (sebelum diskon transaksi)
+		b.WriteString(receiptLine("  Kena pajak", formatRupiah(t.TaxableSubtotal)))
+		if t.ExemptSubtotal > 0 {
+			b.WriteString(receiptLine("  Bebas pajak", formatRupiah(t.ExemptSubtotal)))
+		}
+		b.WriteString(receiptLine("Pajak", formatRupiah(t.Tax)))
+	}
+	b.WriteString(receiptLine("TOTAL", formatRupiah(t.TotalAmount)))
+	b.WriteString(receiptLine("Bayar ("+strings.ToUpper(t.PaymentMethod)+")", formatRupiah(t.AmountPaid)))
+	b.WriteString(receiptLine("Kembali", formatRupiah(t.Change)))
+	if t.PointsEarned > 0 {
+		b.WriteString(receiptLine("Poin didapat", strconv.Itoa(t.PointsEarned)))
+	}
+	b.WriteString(separator + "\n")
+
+	if t.VoidedAt != nil {
+		b.WriteString(receiptCenter("*** DIBATALKAN ***") + "\n")
+	}
+	b.WriteString(receiptCenter("Terima kasih") + "\n")
+
+	return b.String(), nil
+}
+
+// receiptLine menulis label rata kiri dan nilai rata kanan dalam satu baris selebar receiptWidth
+func receiptLine(label, value string) string {
+	space := receiptWidth - len(value) - 1
+	if space < 1 {
+		space = 1
+	}
+	label = receiptTruncateTo(label, space)
+	return fmt.Sprintf("%-*s %s\n", space, label, value)
+}
+
+// receiptCenter menaruh teks di tengah baris struk
+func receiptCenter(text string) string {
+	text = receiptTruncate(text)
+	pad := (receiptWidth - len([]rune(text))) / 2
+	return strings.Repeat(" ", pad) + text
+}
+
+// receiptTruncate memotong teks agar tidak melebihi satu baris struk
+func receiptTruncate(text string) string {
+	return receiptTruncateTo(text, receiptWidth)
+}
+
+func receiptTruncateTo(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) > width {
+		return string(runes[:width])
+	}
+	return text
+}
+
+// formatRupiah memformat angka dengan pemisah ribuan titik, contoh 1500000 -> 1.500.000
+func formatRupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String()
+}
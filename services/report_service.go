@@ -1,22 +1,406 @@
 package services
 
 import (
+	"kasir-api/features"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"math"
+	"time"
 )
 
+// MaxReportDates adalah batas jumlah tanggal dalam satu request report per tanggal
+const MaxReportDates = 62
+
+// DefaultTicketBoundaries adalah batas bucket distribusi transaksi jika client tidak mengirim sendiri
+var DefaultTicketBoundaries = []int{10000, 50000, 100000}
+
 type ReportService struct {
 	repo *repositories.ReportRepository
+	// anomalyMultiplier adalah default kelipatan rata-rata transaksi untuk report anomali
+	anomalyMultiplier float64
+	// timezone adalah default timezone (contoh Asia/Jakarta) untuk menentukan tanggal transaksi di report
+	timezone string
+	// maxDiscountPercent adalah batas diskon (MAX_DISCOUNT_PERCENT) yang ditampilkan di report diskon
+	maxDiscountPercent float64
+	flags              *features.Flags
+}
+
+func NewReportService(repo *repositories.ReportRepository, anomalyMultiplier float64, timezone string, maxDiscountPercent float64, flags *features.Flags) *ReportService {
+	return &ReportService{repo: repo, anomalyMultiplier: anomalyMultiplier, timezone: timezone, maxDiscountPercent: maxDiscountPercent, flags: flags}
+}
+
+// Today mengembalikan tanggal hari ini (YYYY-MM-DD) menurut timezone default report, bukan timezone server
+func (s *ReportService) Today() string {
+	loc, err := time.LoadLocation(s.timezone)
+	if err != nil {
+		// APP_TIMEZONE sudah divalidasi saat startup
+		loc = time.Local
+	}
+	return time.Now().In(loc).Format("2006-01-02")
+}
+
+// resolveTimezone memvalidasi tz dari client, kosong berarti memakai timezone default (APP_TIMEZONE)
+func (s *ReportService) resolveTimezone(tz string) (string, error) {
+	if tz == "" {
+		return s.timezone, nil
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return "", newValidationError("invalid tz %q, use an IANA timezone like Asia/Jakarta", tz)
+	}
+	return tz, nil
+}
+
+// Default dan batas jumlah produk terlaris di report (?top=)
+const (
+	DefaultTopProducts = 5
+	MaxTopProducts     = 20
+)
+
+// GetTodayReport mengambil report hari ini (menurut timezone tz) dengan top produk terlaris
+func (s *ReportService) GetTodayReport(top int, tz string) (*models.ReportResponse, error) {
+	tz, err := s.resolveTimezone(tz)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetTodayReport(top, tz)
+}
+
+// GetReportByDateRange mengambil report range (tanggal menurut timezone tz) dengan top produk terlaris
+func (s *ReportService) GetReportByDateRange(startDate, endDate string, top int, tz string) (*models.ReportResponse, error) {
+	tz, err := s.resolveTimezone(tz)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetReportByDateRange(startDate, endDate, top, tz)
+}
+
+// GetDetailedReport menggabungkan total range, revenue harian, dan top produk dalam satu response
+func (s *ReportService) GetDetailedReport(startDate, endDate string, top int, tz string) (*models.DetailedReportResponse, error) {
+	tz, err := s.resolveTimezone(tz)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := s.repo.GetReportByDateRange(startDate, endDate, top, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	daily, err := s.GetDailyBreakdown(startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DetailedReportResponse{
+		ReportResponse: *summary,
+		DailyRevenue:   daily,
+		TopProducts:    summary.ProdukTerlaris,
+	}, nil
+}
+
+// MaxDailyBreakdownDays adalah rentang maksimum report harian agar response tetap kecil
+const MaxDailyBreakdownDays = 366
+
+// GetDailyBreakdown mengambil revenue dan jumlah transaksi per hari (menurut timezone tz) dalam range
+// Hari tanpa transaksi tetap muncul dengan nilai 0 agar grafik penjualan tidak bolong
+func (s *ReportService) GetDailyBreakdown(startDate, endDate, tz string) ([]models.DailyReport, error) {
+	tz, err := s.resolveTimezone(tz)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, newValidationError("invalid start_date, use YYYY-MM-DD")
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil, newValidationError("invalid end_date, use YYYY-MM-DD")
+	}
+	if int(end.Sub(start).Hours()/24)+1 > MaxDailyBreakdownDays {
+		return nil, newValidationError("date range must not exceed %d days", MaxDailyBreakdownDays)
+	}
+
+	rows, err := s.repo.GetDailyBreakdown(startDate, endDate, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]models.DailyReport, len(rows))
+	for _, row := range rows {
+		byDate[row.Tanggal] = row
+	}
+
+	days := make([]models.DailyReport, 0, len(rows))
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		tanggal := d.Format("2006-01-02")
+		day, ok := byDate[tanggal]
+		if !ok {
+			day = models.DailyReport{Tanggal: tanggal}
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// GetProfitReport menghitung laba kotor dalam range berdasarkan harga modal produk
+func (s *ReportService) GetProfitReport(startDate, endDate string) (*models.ProfitReport, error) {
+	report, err := s.repo.GetProfitReport(startDate, endDate, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Laba dan margin hanya dari item yang harga modalnya diketahui
+	revenueWithCost := report.TotalRevenue - report.RevenueWithoutCost
+	report.Profit = revenueWithCost - report.TotalCost
+	if revenueWithCost > 0 {
+		report.ProfitMarginPercent = math.Round(float64(report.Profit)/float64(revenueWithCost)*10000) / 100
+	}
+	return report, nil
+}
+
+// GetKPIReport menggabungkan ringkasan penjualan dalam range dengan kondisi inventori saat ini
+func (s *ReportService) GetKPIReport(startDate, endDate string) (*models.KPIReport, error) {
+	summary, err := s.repo.GetReportByDateRange(startDate, endDate, 1, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := s.repo.GetInventorySummary()
+	if err != nil {
+		return nil, err
+	}
+
+	profit, err := s.GetProfitReport(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.KPIReport{
+		StartDate:           startDate,
+		EndDate:             endDate,
+		TotalRevenue:        summary.TotalRevenue,
+		TotalTransaksi:      summary.TotalTransaksi,
+		Profit:              profit.Profit,
+		ProfitMarginPercent: profit.ProfitMarginPercent,
+		InventorySummary:    *inventory,
+	}
+	if len(summary.ProdukTerlaris) > 0 {
+		report.ProdukTerlaris = summary.ProdukTerlaris[0]
+	}
+	if summary.TotalTransaksi > 0 {
+		report.AverageTicket = float64(summary.TotalRevenue) / float64(summary.TotalTransaksi)
+	}
+	return report, nil
+}
+
+// GetDailyClosing menyusun ringkasan tutup kasir untuk satu tanggal
+func (s *ReportService) GetDailyClosing(date string) (*models.DailyClosing, error) {
+	summary, err := s.repo.GetReportByDateRange(date, date, DefaultTopProducts, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	itemsSold, err := s.repo.GetItemsSold(date, date, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentMethods, err := s.repo.GetPaymentMethodTotals(date, date, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DailyClosing{
+		Tanggal:        date,
+		TotalRevenue:   summary.TotalRevenue,
+		TotalTransaksi: summary.TotalTransaksi,
+		ItemTerjual:    itemsSold,
+		TopProducts:    summary.ProdukTerlaris,
+		PaymentMethods: paymentMethods,
+	}, nil
 }
 
-func NewReportService(repo *repositories.ReportRepository) *ReportService {
-	return &ReportService{repo: repo}
+// CreateDailySnapshot menghitung ringkasan tutup kasir lalu menyimpannya sebagai snapshot
+func (s *ReportService) CreateDailySnapshot(date string) (*models.DailySnapshot, error) {
+	closing, err := s.GetDailyClosing(date)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SaveDailySnapshot(closing)
 }
 
-func (s *ReportService) GetTodayReport() (*models.ReportResponse, error) {
-	return s.repo.GetTodayReport()
+func (s *ReportService) GetDailySnapshot(date string) (*models.DailySnapshot, error) {
+	return s.repo.GetDailySnapshot(date)
 }
 
-func (s *ReportService) GetReportByDateRange(startDate, endDate string) (*models.ReportResponse, error) {
-	return s.repo.GetReportByDateRange(startDate, endDate)
+func (s *ReportService) GetTicketDistribution(startDate, endDate string, boundaries []int) ([]models.TicketBucket, error) {
+	if len(boundaries) == 0 {
+		boundaries = DefaultTicketBoundaries
+	}
+	for i, b := range boundaries {
+		if b <= 0 {
+			return nil, newValidationError("bucket boundaries must be positive")
+		}
+		if i > 0 && b <= boundaries[i-1] {
+			return nil, newValidationError("bucket boundaries must be in ascending order")
+		}
+	}
+	return s.repo.GetTicketDistribution(startDate, endDate, boundaries, s.timezone)
+}
+
+func (s *ReportService) GetThroughput(startDate, endDate string) (*models.ThroughputReport, error) {
+	return s.repo.GetThroughput(startDate, endDate, s.timezone)
+}
+
+func (s *ReportService) GetBasketSize(startDate, endDate string) ([]models.BasketSize, error) {
+	return s.repo.GetBasketSize(startDate, endDate, s.timezone)
+}
+
+func (s *ReportService) GetReorderByCategory() ([]models.ReorderGroup, error) {
+	return s.repo.GetReorderByCategory()
+}
+
+func (s *ReportService) GetTransactionInterval(startDate, endDate string) (*models.TransactionInterval, error) {
+	return s.repo.GetTransactionInterval(startDate, endDate, s.timezone)
+}
+
+func (s *ReportService) GetHourlyPattern(startDate, endDate string) ([]models.HourlyPattern, error) {
+	return s.repo.GetHourlyPattern(startDate, endDate, s.timezone)
+}
+
+// GetReportForDates mengambil report untuk masing-masing tanggal, tanggal duplikat hanya dihitung sekali
+// Format tanggal dan jumlah maksimal (MaxReportDates) divalidasi di handler
+func (s *ReportService) GetReportForDates(dates []string) ([]models.DailyReport, error) {
+	seen := make(map[string]bool, len(dates))
+	unique := make([]string, 0, len(dates))
+	for _, d := range dates {
+		if !seen[d] {
+			seen[d] = true
+			unique = append(unique, d)
+		}
+	}
+
+	return s.repo.GetReportForDates(unique, s.timezone)
+}
+
+// GetParetoReport menghitung porsi revenue dari 20% produk teratas beserta kurva kumulatifnya
+func (s *ReportService) GetParetoReport(startDate, endDate string) (*models.ParetoReport, error) {
+	products, err := s.repo.GetProductRevenue(startDate, endDate, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ParetoReport{
+		TotalProduk: len(products),
+		Curve:       make([]models.ParetoPoint, 0, len(products)),
+	}
+	for _, p := range products {
+		report.TotalRevenue += p.Revenue
+	}
+	if report.TotalProduk == 0 || report.TotalRevenue == 0 {
+		return report, nil
+	}
+
+	// 20% produk teratas, minimal 1 produk
+	report.TopProductCount = int(math.Ceil(float64(report.TotalProduk) * 0.2))
+
+	cumulative := 0
+	for i, p := range products {
+		cumulative += p.Revenue
+		if i < report.TopProductCount {
+			report.TopRevenueShare = float64(cumulative) / float64(report.TotalRevenue)
+		}
+		report.Curve = append(report.Curve, models.ParetoPoint{
+			ProductRevenue:         p,
+			CumulativeRevenueShare: float64(cumulative) / float64(report.TotalRevenue),
+			CumulativeProductShare: float64(i+1) / float64(report.TotalProduk),
+		})
+	}
+
+	return report, nil
+}
+
+// GetAnomalies mengambil transaksi mencurigakan, multiplier <= 0 berarti pakai default dari config
+func (s *ReportService) GetAnomalies(startDate, endDate string, multiplier float64) (*models.AnomalyReport, error) {
+	if multiplier <= 0 {
+		multiplier = s.anomalyMultiplier
+	}
+	return s.repo.GetAnomalies(startDate, endDate, multiplier, s.timezone)
+}
+
+func (s *ReportService) GetCategoryAttachRate(startDate, endDate string) ([]models.CategoryAttachRate, error) {
+	return s.repo.GetCategoryAttachRate(startDate, endDate, s.timezone)
+}
+
+// GetRevenueByCategory mengambil revenue per kategori beserta pajak dan selisih pembulatan
+// agar jumlahnya sama dengan total_revenue /api/report
+func (s *ReportService) GetRevenueByCategory(startDate, endDate string) (*models.CategoryRevenueReport, error) {
+	report, err := s.repo.GetRevenueByCategory(startDate, endDate, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	allocated := 0
+	for _, c := range report.Categories {
+		allocated += c.Revenue
+	}
+	report.RoundingAdjustment = report.TotalRevenue - report.Tax - allocated
+	return report, nil
+}
+
+func (s *ReportService) GetCustomerMix(startDate, endDate string) ([]models.CustomerMixBucket, error) {
+	return s.repo.GetCustomerMix(startDate, endDate, s.timezone)
+}
+
+// GetStockCover menghitung days of cover (stok / rata-rata terjual per hari) untuk semua produk
+// Produk dengan days of cover < minDays ditandai, produk yang tidak terjual tidak pernah ditandai
+func (s *ReportService) GetStockCover(days int, minDays float64) ([]models.StockCover, error) {
+	items, err := s.repo.GetStockCover(days)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		item := &items[i]
+		item.AvgPerDay = float64(item.TotalSold) / float64(days)
+		if item.AvgPerDay > 0 {
+			cover := float64(item.Stock) / item.AvgPerDay
+			item.DaysOfCover = &cover
+			item.BelowMinDays = cover < minDays
+		}
+	}
+	return items, nil
+}
+
+func (s *ReportService) GetCategoryTrend(categoryID int, period string, count int) (*models.CategoryTrend, error) {
+	points, err := s.repo.GetCategoryTrend(categoryID, period, count, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CategoryTrend{CategoryID: categoryID, Period: period, Points: points}, nil
+}
+
+// GetDiscountReport mengambil ringkasan diskon dalam range beserta batas diskon yang berlaku
+func (s *ReportService) GetDiscountReport(startDate, endDate string) (*models.DiscountReport, error) {
+	report, err := s.repo.GetDiscountReport(startDate, endDate, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+	report.MaxDiscountPercent = s.maxDiscountPercent
+	return report, nil
+}
+
+// GetTaxReport mengambil pajak terkumpul dalam range, atau report bernilai 0 jika fitur pajak dimatikan
+func (s *ReportService) GetTaxReport(startDate, endDate string) (*models.TaxReport, error) {
+	if !s.flags.Enabled(features.Tax) {
+		return &models.TaxReport{StartDate: startDate, EndDate: endDate}, nil
+	}
+
+	report, err := s.repo.GetTaxReport(startDate, endDate, s.timezone)
+	if err != nil {
+		return nil, err
+	}
+	report.TaxEnabled = true
+	return report, nil
 }
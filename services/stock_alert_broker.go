@@ -0,0 +1,53 @@
+package services
+
+import (
+	"kasir-api/models"
+	"sync"
+)
+
+// StockAlertBroker meneruskan alert stok menipis ke semua client SSE yang sedang terhubung
+type StockAlertBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan models.Product]struct{}
+}
+
+// NewStockAlertBroker membuat instance baru dari StockAlertBroker
+func NewStockAlertBroker() *StockAlertBroker {
+	return &StockAlertBroker{subscribers: make(map[chan models.Product]struct{})}
+}
+
+// Subscribe mendaftarkan client baru dan mengembalikan channel untuk menerima alert
+// Channel di-buffer agar publish tidak tertahan oleh client yang lambat
+func (b *StockAlertBroker) Subscribe() chan models.Product {
+	ch := make(chan models.Product, 16)
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe menghapus client, dipanggil saat koneksi client terputus
+func (b *StockAlertBroker) Unsubscribe(ch chan models.Product) {
+	b.mu.Lock()
+	delete(b.subscribers, ch)
+	b.mu.Unlock()
+}
+
+// HasSubscribers dipakai untuk melewati query alert jika tidak ada client yang mendengarkan
+func (b *StockAlertBroker) HasSubscribers() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers) > 0
+}
+
+// Publish mengirim alert ke semua client, alert dibuang untuk client yang buffer-nya penuh
+func (b *StockAlertBroker) Publish(product models.Product) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- product:
+		default:
+		}
+	}
+}
@@ -1,20 +1,256 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"kasir-api/features"
 	"kasir-api/models"
 	"kasir-api/repositories"
+	"log"
+	"strings"
 )
 
 // Bertugas sebagai penghubung antara handler dan repository
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo        *repositories.TransactionRepository
+	loyalty     models.LoyaltyConfig
+	stockAlerts *StockAlertBroker
+	flags       *features.Flags
+	// storeName dicetak sebagai header struk
+	storeName string
+	// timezone toko (APP_TIMEZONE) untuk filter tanggal transaksi, sama dengan report
+	timezone string
+	// maxDiscountPercent adalah batas diskon item dan diskon transaksi (MAX_DISCOUNT_PERCENT)
+	maxDiscountPercent float64
 }
 
 // NewTransactionService membuat instance baru dari TransactionService
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+// stockAlerts menerima produk yang stoknya menipis setelah checkout
+func NewTransactionService(repo *repositories.TransactionRepository, loyalty models.LoyaltyConfig, stockAlerts *StockAlertBroker, flags *features.Flags, storeName, timezone string, maxDiscountPercent float64) *TransactionService {
+	return &TransactionService{repo: repo, loyalty: loyalty, stockAlerts: stockAlerts, flags: flags, storeName: storeName, timezone: timezone, maxDiscountPercent: maxDiscountPercent}
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem) (*models.Transaction, error) {
-	return s.repo.CreateTransaction(items)
+func (s *TransactionService) Checkout(req *models.CheckoutRequest) (*models.Transaction, error) {
+	transaction, _, err := s.checkout(req)
+	return transaction, err
+}
+
+// checkout menjalankan checkout dan mengembalikan created=false jika idempotency key sudah pernah dipakai
+// Pada kasus itu transaksi yang dikembalikan adalah transaksi asli, tidak ada stok atau poin yang berubah
+func (s *TransactionService) checkout(req *models.CheckoutRequest) (*models.Transaction, bool, error) {
+	if err := validateCheckoutItems(req.Items, s.flags.Enabled(features.Discount)); err != nil {
+		return nil, false, err
+	}
+
+	req.IdempotencyKey = strings.TrimSpace(req.IdempotencyKey)
+	if req.IdempotencyKey != "" {
+		existing, err := s.repo.GetByIdempotencyKey(req.IdempotencyKey)
+		if err == nil {
+			return existing, false, nil
+		}
+		var notFound *repositories.NotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, false, err
+		}
+	}
+
+	req.CustomerPhone = strings.TrimSpace(req.CustomerPhone)
+	req.CustomerName = strings.TrimSpace(req.CustomerName)
+	if req.RedeemPoints < 0 {
+		return nil, false, newValidationError("redeem_points must not be negative")
+	}
+	if req.RedeemPoints > 0 && req.CustomerPhone == "" {
+		return nil, false, newValidationError("customer_phone is required to redeem points")
+	}
+	req.PaymentMethod = strings.ToLower(strings.TrimSpace(req.PaymentMethod))
+	switch req.PaymentMethod {
+	case "":
+		req.PaymentMethod = models.PaymentCash
+	case models.PaymentCash, models.PaymentCard, models.PaymentQRIS:
+	default:
+		return nil, false, newValidationError("unknown payment_method %s, use cash, card, or qris", req.PaymentMethod)
+	}
+	if req.AmountPaid < 0 {
+		return nil, false, newValidationError("amount_paid must not be negative")
+	}
+	if req.DiscountAmount < 0 {
+		return nil, false, newValidationError("discount_amount must not be negative")
+	}
+	if req.TaxPercent < 0 || req.TaxPercent > 100 {
+		return nil, false, newValidationError("tax_percent must be between 0 and 100")
+	}
+	if req.DiscountAmount > 0 && !s.flags.Enabled(features.Discount) {
+		return nil, false, newValidationError("discount feature is disabled")
+	}
+	if req.TaxPercent > 0 && !s.flags.Enabled(features.Tax) {
+		return nil, false, newValidationError("tax feature is disabled")
+	}
+
+	// Saat loyalty dimatikan, pelanggan tetap tercatat tapi tidak mendapat atau menukar poin
+	loyalty := s.loyalty
+	if !s.flags.Enabled(features.Loyalty) {
+		if req.RedeemPoints > 0 {
+			return nil, false, newValidationError("loyalty feature is disabled")
+		}
+		loyalty.SpendPerPoint = 0
+	}
+
+	transaction, err := s.repo.CreateTransaction(req, loyalty, s.maxDiscountPercent)
+	var rejected *repositories.CheckoutRejectedError
+	if errors.As(err, &rejected) {
+		return nil, false, newValidationError("%s", rejected.Reason)
+	}
+	var limit *repositories.DiscountLimitError
+	if errors.As(err, &limit) {
+		return nil, false, fmt.Errorf("%w: %s", ErrDiscountLimit, limit.Error())
+	}
+	if errors.Is(err, repositories.ErrDuplicateIdempotencyKey) {
+		// Dua request dengan key yang sama masuk hampir bersamaan dan yang lain menang, kembalikan transaksinya
+		existing, err := s.repo.GetByIdempotencyKey(req.IdempotencyKey)
+		if err != nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.publishLowStock(transaction)
+	return transaction, true, nil
+}
+
+// validateCheckoutItems menolak keranjang kosong, product_id tidak valid, qty <= 0, diskon item negatif,
+// dan diskon item saat fitur diskon dimatikan (discountEnabled false)
+// agar transaksi kosong atau qty negatif tidak ikut terhitung di laporan
+func validateCheckoutItems(items []models.CheckoutItem, discountEnabled bool) error {
+	if len(items) == 0 {
+		return newValidationError("items must not be empty")
+	}
+	for i, item := range items {
+		if item.ProductID <= 0 {
+			return newValidationError("items[%d]: product_id must be a positive integer", i)
+		}
+		if item.Quantity <= 0 {
+			return newValidationError("items[%d]: quantity must be greater than zero", i)
+		}
+		if item.Discount < 0 {
+			return newValidationError("items[%d]: discount must not be negative", i)
+		}
+		// Diskon per item ikut dimatikan oleh FEATURE_DISCOUNT, sama seperti discount_amount
+		if item.Discount > 0 && !discountEnabled {
+			return newValidationError("items[%d]: discount feature is disabled", i)
+		}
+	}
+	return nil
+}
+
+// publishLowStock mengirim alert untuk produk di transaksi yang stoknya sudah <= min_stock
+// Gagal mengambil data alert tidak menggagalkan checkout yang sudah commit, cukup di-log
+func (s *TransactionService) publishLowStock(transaction *models.Transaction) {
+	if !s.stockAlerts.HasSubscribers() {
+		return
+	}
+
+	productIDs := make([]int, 0, len(transaction.Details))
+	for _, d := range transaction.Details {
+		productIDs = append(productIDs, d.ProductID)
+	}
+
+	products, err := s.repo.GetLowStockAmong(productIDs)
+	if err != nil {
+		log.Print("failed to load low stock products for alert: ", err)
+		return
+	}
+
+	for _, p := range products {
+		setStockStatus(&p)
+		s.stockAlerts.Publish(p)
+	}
+}
+
+// GetRecentForProduct mengambil riwayat penjualan terakhir untuk satu produk
+func (s *TransactionService) GetRecentForProduct(productID, limit int) ([]models.ProductSale, error) {
+	return s.repo.GetRecentForProduct(productID, limit)
+}
+
+// StreamDetails meneruskan setiap item transaksi dalam range ke fn (dipakai untuk export CSV)
+func (s *TransactionService) StreamDetails(startDate, endDate string, fn func(models.TransactionDetailExport) error) error {
+	return s.repo.StreamDetails(startDate, endDate, s.timezone, fn)
+}
+
+// VoidByDateRange membatalkan semua transaksi dalam range tanggal dan mengembalikan stoknya
+func (s *TransactionService) VoidByDateRange(startDate, endDate string) (*models.VoidBatchResult, error) {
+	return s.repo.VoidByDateRange(startDate, endDate, s.timezone)
+}
+
+// GetAll mengambil satu halaman riwayat transaksi sesuai filter, page dimulai dari 1
+func (s *TransactionService) GetAll(filter models.TransactionFilter, page, size int) (*models.TransactionPage, error) {
+	transactions, total, err := s.repo.GetAll(filter, s.timezone, size, (page-1)*size)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TransactionPage{Data: transactions, Total: total, Page: page, Size: size}, nil
+}
+
+// GetByID mengambil satu transaksi beserta detailnya
+func (s *TransactionService) GetByID(id int) (*models.Transaction, error) {
+	return s.repo.GetByID(id)
+}
+
+// Cancel membatalkan transaksi lalu mengembalikan transaksi dengan status terbarunya
+func (s *TransactionService) Cancel(id int) (*models.Transaction, error) {
+	if err := s.repo.CancelTransaction(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// GetPageByCursor mengambil satu halaman transaksi dan menghitung cursor untuk halaman berikutnya
+// Jika jumlah data kurang dari limit berarti sudah halaman terakhir
+func (s *TransactionService) GetPageByCursor(cursor, limit int) (*models.TransactionCursorPage, error) {
+	transactions, err := s.repo.GetPageByCursor(cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TransactionCursorPage{Data: transactions}
+	if len(transactions) == limit && limit > 0 {
+		next := transactions[len(transactions)-1].ID
+		page.NextCursor = &next
+	}
+	return page, nil
+}
+
+// BatchCheckout memproses checkout dari antrian offline satu per satu
+// Setiap entri wajib punya idempotency_key agar upload ulang tidak menggandakan penjualan
+// Entri yang gagal tidak membatalkan entri lainnya
+func (s *TransactionService) BatchCheckout(reqs []models.CheckoutRequest) []models.BatchCheckoutResult {
+	results := make([]models.BatchCheckoutResult, 0, len(reqs))
+	for i := range reqs {
+		req := &reqs[i]
+		result := models.BatchCheckoutResult{Index: i, IdempotencyKey: strings.TrimSpace(req.IdempotencyKey)}
+
+		if result.IdempotencyKey == "" {
+			result.Status = models.BatchCheckoutFailed
+			result.Error = "idempotency_key is required"
+			results = append(results, result)
+			continue
+		}
+
+		transaction, created, err := s.checkout(req)
+		switch {
+		case err != nil:
+			result.Status = models.BatchCheckoutFailed
+			result.Error = err.Error()
+		case created:
+			result.Status = models.BatchCheckoutCreated
+			result.TransactionID = &transaction.ID
+		default:
+			result.Status = models.BatchCheckoutDuplicate
+			result.TransactionID = &transaction.ID
+		}
+		results = append(results, result)
+	}
+	return results
 }
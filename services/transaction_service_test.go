@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"kasir-api/models"
+	"testing"
+)
+
+func TestValidateCheckoutItems(t *testing.T) {
+	cases := []struct {
+		name  string
+		items []models.CheckoutItem
+		// discountDisabled mensimulasikan FEATURE_DISCOUNT=false
+		discountDisabled bool
+	}{
+		{"empty items", nil, false},
+		{"zero product_id", []models.CheckoutItem{{ProductID: 0, Quantity: 1}}, false},
+		{"negative product_id", []models.CheckoutItem{{ProductID: -1, Quantity: 1}}, false},
+		{"zero quantity", []models.CheckoutItem{{ProductID: 1, Quantity: 0}}, false},
+		{"negative quantity", []models.CheckoutItem{{ProductID: 1, Quantity: -2}}, false},
+		{"negative discount", []models.CheckoutItem{{ProductID: 1, Quantity: 1, Discount: -100}}, false},
+		{"invalid second item", []models.CheckoutItem{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 0}}, false},
+		{"item discount with feature disabled", []models.CheckoutItem{{ProductID: 1, Quantity: 1, Discount: 500}}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCheckoutItems(tc.items, !tc.discountDisabled)
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("err = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
+
+func TestValidateCheckoutItemsAcceptsValidCart(t *testing.T) {
+	items := []models.CheckoutItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1, Discount: 500},
+	}
+	if err := validateCheckoutItems(items, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}